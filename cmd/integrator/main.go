@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,6 +18,9 @@ import (
 
 	"yandex-messenger-bridge/config"
 	"yandex-messenger-bridge/internal/repository/postgres"
+	"yandex-messenger-bridge/internal/service/cache"
+	"yandex-messenger-bridge/internal/service/catalog"
+	"yandex-messenger-bridge/internal/service/delivery"
 	"yandex-messenger-bridge/internal/service/encryption"
 	"yandex-messenger-bridge/internal/service/webhook"
 	"yandex-messenger-bridge/internal/transport/api"
@@ -53,6 +57,23 @@ func main() {
 		return
 	}
 
+	// Подкоманды работают с базой и завершаются без запуска сервера
+	if flag.NArg() > 0 {
+		repo := postgres.NewIntegrationRepository(db, encryption.NewEncryptor(cfg.EncryptionKey))
+		var err error
+		switch flag.Arg(0) {
+		case "templates":
+			err = runTemplatesCommand(context.Background(), repo, flag.Args()[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", flag.Arg(0))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Инициализируем сервисы
 	encryptor := encryption.NewEncryptor(cfg.EncryptionKey)
 	yandexClient := yandex.NewClient("") // Токен будет подставляться динамически
@@ -60,37 +81,70 @@ func main() {
 	// Инициализируем репозитории
 	integrationRepo := postgres.NewIntegrationRepository(db, encryptor)
 
+	// Встроенный каталог шаблонов: новые шаблоны устанавливаются, неизменённые локально - обновляются
+	if cfg.CatalogSync {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if _, err := catalog.Sync(ctx, integrationRepo); err != nil {
+			log.Error().Err(err).Msg("Failed to sync template catalog")
+		}
+		cancel()
+	}
+
+	// Кэш экземпляров и шаблонов для вебхуков; изменения с других реплик приходят через LISTEN/NOTIFY
+	webhookCache := cache.NewCache(integrationRepo, cfg.TemplateCacheTTL)
+	var cacheListener *cache.Listener
+	if webhookCache.Enabled() {
+		cacheListener = cache.NewListener(webhookCache, cfg.DatabaseDSN)
+		if err := cacheListener.Start(); err != nil {
+			log.Error().Err(err).Msg("Failed to listen for cache invalidation, entries will expire by TTL")
+			cacheListener = nil
+		}
+	}
+
 	// Инициализируем обработчики вебхуков
 	webhookHandler := webhook.NewHandler(
 		integrationRepo,
 		yandexClient,
 		encryptor,
+		webhookCache,
 		webhook.Config{
 			GitLabTimeout:       10 * time.Second,
 			AlertmanagerTimeout: 5 * time.Second,
 			JiraTimeout:         10 * time.Second,
-			MaxRetries:          3,
+			MaxRetries:          cfg.MaxRetries,
+			TrustProxyHeaders:   cfg.TrustProxyHeaders,
+			AttachmentAllowlist: cfg.AttachmentAllowlist,
+		},
+	)
+
+	// Запускаем воркеры очереди отправки
+	deliveryWorker := delivery.NewWorker(
+		integrationRepo,
+		encryptor,
+		delivery.Config{
+			Workers:      cfg.DeliveryWorkers,
+			PollInterval: cfg.DeliveryPollInterval,
+
+			AttachmentAllowlist: cfg.AttachmentAllowlist,
 		},
 	)
+	deliveryWorker.Start()
+
+	// Очистка истории доставок по сроку хранения
+	historyCleaner := delivery.NewCleaner(integrationRepo, cfg.DeliveryLogRetention, time.Hour)
+	historyCleaner.Start()
 
 	// Создаем Echo сервер
 	e := echo.New()
 
 	// Middleware
-	e.Use(middleware.MethodOverrideWithConfig(middleware.MethodOverrideConfig{
-		Getter: func(c echo.Context) string {
-			if method := c.Request().Header.Get("X-HTTP-Method-Override"); method != "" {
-				return method
-			}
-			return c.FormValue("_method")
-		},
-	}))
+	e.Use(authMiddleware.MethodOverride())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{"http://localhost:8080"},
 		AllowMethods:     []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-API-Key"},
 		AllowCredentials: true,
 	}))
 
@@ -101,23 +155,65 @@ func main() {
 	// Публичные API эндпоинты
 	authAPI := api.NewAuthAPI(integrationRepo, cfg.JWTSecret)
 	usersAPI := api.NewUsersAPI(integrationRepo, cfg.JWTSecret)
+	templateAPI := api.NewTemplateAPI(integrationRepo)
+	instanceAPI := api.NewInstanceAPI(integrationRepo, encryptor, webhookHandler)
 
 	e.POST("/api/v1/login", authAPI.Login)
 	e.POST("/api/v1/logout", authAPI.Logout)
 
 	// Публичные веб-эндпоинты
-	webHandler := web.NewHandler(integrationRepo, encryptor)
+	webHandler := web.NewHandler(integrationRepo, encryptor, webhookHandler)
 	e.GET("/login", webHandler.LoginPage)
 	e.GET("/change-password", webHandler.ChangePasswordPage)
 
 	// Защищенные API эндпоинты
-	authMw := authMiddleware.NewAuthMiddleware(cfg.JWTSecret)
+	authMw := authMiddleware.NewAuthMiddleware(cfg.JWTSecret, integrationRepo)
 	apiGroup := e.Group("/api/v1")
 	apiGroup.Use(authMw.RequireAuth)
 	{
 		apiGroup.GET("/me", authAPI.Me)
 		apiGroup.POST("/change-password", authAPI.ChangePassword)
 
+		// Шаблоны
+		apiGroup.GET("/templates", templateAPI.List)
+		apiGroup.POST("/templates", templateAPI.Create)
+		apiGroup.POST("/templates/preview", templateAPI.Preview)
+		apiGroup.GET("/templates/export", templateAPI.Export)
+		apiGroup.POST("/templates/import", templateAPI.Import)
+		apiGroup.GET("/templates/:id", templateAPI.Get)
+		apiGroup.PUT("/templates/:id", templateAPI.Update)
+		apiGroup.DELETE("/templates/:id", templateAPI.Delete)
+		apiGroup.POST("/templates/:id/tests", templateAPI.RunTests)
+		apiGroup.GET("/templates/:id/samples", templateAPI.ListSamples)
+		apiGroup.POST("/templates/:id/samples", templateAPI.SaveSample)
+		apiGroup.GET("/templates/:id/samples/:sample_id", templateAPI.GetSample)
+		apiGroup.DELETE("/templates/:id/samples/:sample_id", templateAPI.DeleteSample)
+		apiGroup.GET("/templates/:id/versions", templateAPI.ListVersions)
+		apiGroup.GET("/templates/:id/versions/:version", templateAPI.GetVersion)
+		apiGroup.POST("/templates/:id/versions/:version/rollback", templateAPI.Rollback)
+		apiGroup.GET("/templates/:id/diff", templateAPI.Diff)
+
+		// Экземпляры интеграций
+		apiGroup.GET("/instances", instanceAPI.List)
+		apiGroup.POST("/instances", instanceAPI.Create)
+		apiGroup.GET("/instances/:id", instanceAPI.Get)
+		apiGroup.PUT("/instances/:id", instanceAPI.Update)
+		apiGroup.DELETE("/instances/:id", instanceAPI.Delete)
+		apiGroup.POST("/instances/:id/enable", instanceAPI.Enable)
+		apiGroup.POST("/instances/:id/disable", instanceAPI.Disable)
+		apiGroup.POST("/instances/:id/rotate-token", instanceAPI.RotateToken)
+		apiGroup.GET("/instances/:id/last-webhook", instanceAPI.LastWebhook)
+		apiGroup.POST("/instances/:id/last-webhook/sample", instanceAPI.CaptureSample)
+		apiGroup.POST("/instances/:id/test", instanceAPI.Test)
+		apiGroup.POST("/instances/:id/replay", instanceAPI.Replay)
+		apiGroup.POST("/instances/:id/preview", instanceAPI.Preview)
+		apiGroup.GET("/instances/:id/deliveries", instanceAPI.Deliveries)
+		apiGroup.GET("/instances/:id/dead-letters", instanceAPI.DeadLetters)
+		apiGroup.POST("/instances/:id/dead-letters/redeliver", instanceAPI.RedeliverAllDeadLetters)
+		apiGroup.POST("/instances/:id/dead-letters/:letter_id/redeliver", instanceAPI.RedeliverDeadLetter)
+		apiGroup.DELETE("/instances/:id/dead-letters/:letter_id", instanceAPI.DiscardDeadLetter)
+		apiGroup.DELETE("/instances/:id/dead-letters", instanceAPI.DiscardAllDeadLetters)
+
 		// Админские API для управления пользователями
 		adminGroup := apiGroup.Group("/admin")
 		adminGroup.Use(authMw.RequireAdmin)
@@ -156,7 +252,17 @@ func main() {
 		webGroup.GET("/admin/templates/new", webHandler.TemplateEditPage)
 		webGroup.GET("/admin/templates/:id/edit", webHandler.TemplateEditPage)
 		webGroup.POST("/admin/templates", webHandler.CreateTemplate)
+		webGroup.POST("/admin/templates/preview", webHandler.PreviewTemplate)
+		webGroup.POST("/admin/templates/tests", webHandler.RunTemplateTests)
+		webGroup.GET("/admin/templates/export", webHandler.ExportTemplates)
+		webGroup.GET("/admin/templates/import", webHandler.TemplatesImportPage)
+		webGroup.POST("/admin/templates/import", webHandler.ImportTemplates)
 		webGroup.DELETE("/admin/templates/:id", webHandler.DeleteTemplate)
+		webGroup.POST("/admin/templates/:id/samples", webHandler.SaveTemplateSample)
+		webGroup.DELETE("/admin/templates/:id/samples/:sample_id", webHandler.DeleteTemplateSample)
+		webGroup.GET("/admin/templates/:id/versions", webHandler.TemplateVersionsPage)
+		webGroup.GET("/admin/templates/:id/versions/diff", webHandler.TemplateVersionDiff)
+		webGroup.POST("/admin/templates/:id/versions/:version/rollback", webHandler.RollbackTemplateVersion)
 
 		// Пользовательские маршруты для шаблонов и экземпляров
 		webGroup.GET("/templates", webHandler.TemplatesUserPage)
@@ -164,13 +270,28 @@ func main() {
 		webGroup.POST("/templates/custom", webHandler.CreateCustomInstance)
 		webGroup.POST("/instances/custom", webHandler.CreateCustomInstance)
 		webGroup.GET("/templates/:id/use", webHandler.InstanceCreatePage)
+		webGroup.GET("/templates/:id/samples/:sample_id", webHandler.TemplateSample)
 		webGroup.POST("/instances", webHandler.CreateInstance)
 		webGroup.GET("/instances", webHandler.InstancesListPage)
 		webGroup.POST("/instances/:id/test", webHandler.TestInstance)
+		webGroup.POST("/instances/:id/replay", webHandler.ReplayInstance)
 		webGroup.DELETE("/instances/:id", webHandler.DeleteInstance)
 		webGroup.GET("/instances/:id/edit", webHandler.EditInstanceForm)
+		webGroup.POST("/instances/:id/preview", webHandler.PreviewInstanceTemplate)
 		webGroup.PUT("/instances/:id", webHandler.UpdateInstance)
 		webGroup.GET("/instances/:id/last-webhook", webHandler.GetLastWebhook)
+		webGroup.POST("/instances/:id/last-webhook/sample", webHandler.CaptureSample)
+		webGroup.GET("/instances/:id/history", webHandler.InstanceHistoryPage)
+		webGroup.GET("/instances/:id/dead-letters", webHandler.DeadLettersPage)
+		webGroup.POST("/instances/:id/dead-letters/redeliver", webHandler.RedeliverAllDeadLetters)
+		webGroup.POST("/instances/:id/dead-letters/:letter_id/redeliver", webHandler.RedeliverDeadLetter)
+		webGroup.DELETE("/instances/:id/dead-letters/:letter_id", webHandler.DiscardDeadLetter)
+		webGroup.DELETE("/instances/:id/dead-letters", webHandler.DiscardAllDeadLetters)
+
+		// Персональные API-ключи
+		webGroup.GET("/api-keys", webHandler.APIKeysPage)
+		webGroup.POST("/api-keys", webHandler.CreateAPIKey)
+		webGroup.DELETE("/api-keys/:id", webHandler.RevokeAPIKey)
 	}
 
 	// Статические файлы (иконки уже в образе)
@@ -187,9 +308,18 @@ func main() {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to shutdown server")
+		log.Error().Err(err).Msg("Failed to shutdown server")
+	}
+
+	// Дожидаемся отправки сообщений, которые уже взяты воркерами
+	if err := deliveryWorker.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Delivery workers did not drain in time")
+	}
+	historyCleaner.Stop()
+	if cacheListener != nil {
+		cacheListener.Stop()
 	}
 }
\ No newline at end of file
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/bundle"
+	"yandex-messenger-bridge/internal/service/catalog"
+)
+
+const templatesUsage = `usage:
+  integrator templates export [-format json|yaml] [-o file] [name ...]
+  integrator templates import [-on-conflict skip|overwrite|rename] file|-
+  integrator templates catalog`
+
+// runTemplatesCommand выполняет подкоманду templates: экспорт и импорт пакетов шаблонов, синхронизацию каталога.
+// Команда работает от имени администратора без автора: публичные шаблоны остаются публичными.
+func runTemplatesCommand(ctx context.Context, repo _interface.IntegrationRepository, args []string) error {
+	if len(args) == 0 {
+		return errors.New(templatesUsage)
+	}
+
+	switch args[0] {
+	case "export":
+		return exportTemplates(ctx, repo, args[1:])
+	case "import":
+		return importTemplates(ctx, repo, args[1:])
+	case "catalog":
+		return syncCatalog(ctx, repo)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], templatesUsage)
+	}
+}
+
+// exportTemplates выгружает шаблоны с указанными названиями (без названий - все) в файл или stdout
+func exportTemplates(ctx context.Context, repo _interface.IntegrationRepository, args []string) error {
+	flags := flag.NewFlagSet("templates export", flag.ContinueOnError)
+	format := flags.String("format", "", "bundle format: json or yaml (default: by file extension, else json)")
+	output := flags.String("o", "", "output file (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = *output
+	}
+
+	var list []*domain.Template
+	if flags.NArg() == 0 {
+		all, err := repo.ListAllTemplates(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list templates: %w", err)
+		}
+		list = all
+	}
+	for _, name := range flags.Args() {
+		found, err := repo.FindTemplatesByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to find template %q: %w", name, err)
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("template %q not found", name)
+		}
+		list = append(list, found...)
+	}
+
+	b, err := bundle.Export(ctx, repo, list)
+	if err != nil {
+		return err
+	}
+	data, err := bundle.Marshal(b, bundle.Format(*format))
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d templates to %s\n", len(list), *output)
+	return nil
+}
+
+// importTemplates загружает пакет из файла или stdin ("-") и печатает итог по каждому шаблону
+func importTemplates(ctx context.Context, repo _interface.IntegrationRepository, args []string) error {
+	flags := flag.NewFlagSet("templates import", flag.ContinueOnError)
+	onConflict := flags.String("on-conflict", bundle.ConflictSkip, "existing template with the same name: skip, overwrite or rename")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(templatesUsage)
+	}
+	if !bundle.ValidConflict(*onConflict) {
+		return fmt.Errorf("invalid -on-conflict %q: must be skip, overwrite or rename", *onConflict)
+	}
+
+	var src io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		src = file
+	}
+	data, err := io.ReadAll(io.LimitReader(src, bundle.MaxSize))
+	if err != nil {
+		return err
+	}
+
+	b, err := bundle.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+
+	report := bundle.Import(ctx, repo, b, bundle.Options{OnConflict: *onConflict, Admin: true})
+	for _, result := range report.Results {
+		switch {
+		case result.Error != "":
+			fmt.Printf("%-8s %s: %s\n", result.Action, result.Name, result.Error)
+		case result.Action == bundle.ActionRenamed:
+			fmt.Printf("%-8s %s -> %s\n", result.Action, result.Name, result.ImportedAs)
+		default:
+			fmt.Printf("%-8s %s\n", result.Action, result.Name)
+		}
+	}
+	if failed := report.Count(bundle.ActionFailed); failed > 0 {
+		return fmt.Errorf("%d of %d templates failed to import", failed, len(report.Results))
+	}
+	return nil
+}
+
+// syncCatalog синхронизирует встроенный каталог шаблонов (как при запуске сервера) и печатает итог по каждому шаблону
+func syncCatalog(ctx context.Context, repo _interface.IntegrationRepository) error {
+	results, err := catalog.Sync(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			fmt.Printf("%-10s %s r%d: %s\n", result.Action, result.Key, result.Revision, result.Error)
+			continue
+		}
+		fmt.Printf("%-10s %s r%d (%s)\n", result.Action, result.Key, result.Revision, result.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d catalog templates failed to sync", failed, len(results))
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -10,6 +14,27 @@ type Config struct {
 	JWTSecret     string
 	BaseURL       string
 	EncryptionKey string
+
+	// Очередь отправки
+	MaxRetries           int
+	DeliveryWorkers      int
+	DeliveryPollInterval time.Duration
+	ShutdownTimeout      time.Duration
+
+	// Срок хранения истории доставок (0 - хранить бессрочно)
+	DeliveryLogRetention time.Duration
+
+	// Внутренние хосты и подсети, с которых разрешено скачивать вложения (image_url, file_url)
+	AttachmentAllowlist []string
+
+	// Доверять X-Real-IP / X-Forwarded-For при проверке адреса отправителя вебхука
+	TrustProxyHeaders bool
+
+	// Срок жизни кэша экземпляров и шаблонов для вебхуков (0 - кэш выключен)
+	TemplateCacheTTL time.Duration
+
+	// Устанавливать и обновлять шаблоны встроенного каталога при запуске
+	CatalogSync bool
 }
 
 func Load() *Config {
@@ -21,6 +46,21 @@ func Load() *Config {
 		JWTSecret:     getEnv("JWT_SECRET", "your-secret-key"),
 		BaseURL:       getEnv("BASE_URL", "http://localhost:8080"),
 		EncryptionKey: getEnv("ENCRYPTION_KEY", "32-byte-key-for-aes-256-encryption"),
+
+		MaxRetries:           getEnvInt("MAX_RETRIES", 3),
+		DeliveryWorkers:      getEnvInt("DELIVERY_WORKERS", 4),
+		DeliveryPollInterval: getEnvDuration("DELIVERY_POLL_INTERVAL", time.Second),
+		ShutdownTimeout:      getEnvDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+
+		DeliveryLogRetention: getEnvDuration("DELIVERY_LOG_RETENTION", 30*24*time.Hour),
+
+		AttachmentAllowlist: getEnvList("ATTACHMENT_ALLOWLIST"),
+
+		TrustProxyHeaders: getEnvBool("TRUST_PROXY_HEADERS", false),
+
+		TemplateCacheTTL: getEnvDuration("TEMPLATE_CACHE_TTL", 5*time.Minute),
+
+		CatalogSync: getEnvBool("CATALOG_SYNC", true),
 	}
 }
 
@@ -30,3 +70,31 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(viper.GetString(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(viper.GetString(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList разбирает список, разделённый запятыми или пробелами
+func getEnvList(key string) []string {
+	return strings.FieldsFunc(viper.GetString(key), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(viper.GetString(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -1,44 +1,47 @@
 module yandex-messenger-bridge
 
-go 1.23
+go 1.23.0
 
 require (
 	github.com/a-h/templ v0.3.1001
-    github.com/golang-jwt/jwt/v5 v5.2.1
-    github.com/jmoiron/sqlx v1.4.0
-    github.com/labstack/echo/v4 v4.12.0
-    github.com/lib/pq v1.10.9
-    github.com/osteele/liquid v1.6.0
-    github.com/rs/zerolog v1.33.0
-    github.com/spf13/viper v1.19.0
-    golang.org/x/crypto v0.25.0
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/jmoiron/sqlx v1.4.0
+	github.com/labstack/echo/v4 v4.12.0
+	github.com/lib/pq v1.10.9
+	github.com/osteele/liquid v1.6.0
+	github.com/osteele/tuesday v1.0.3
+	github.com/rs/zerolog v1.33.0
+	github.com/spf13/viper v1.19.0
+	golang.org/x/crypto v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-    github.com/fsnotify/fsnotify v1.7.0 // indirect
-    github.com/hashicorp/hcl v1.0.0 // indirect
-    github.com/labstack/gommon v0.4.2 // indirect
-    github.com/magiconair/properties v1.8.7 // indirect
-    github.com/mattn/go-colorable v0.1.13 // indirect
-    github.com/mattn/go-isatty v0.0.20 // indirect
-    github.com/mitchellh/mapstructure v1.5.0 // indirect
-    github.com/pelletier/go-toml/v2 v2.2.2 // indirect
-    github.com/sagikazarmark/locafero v0.4.0 // indirect
-    github.com/sagikazarmark/slog-shim v0.1.0 // indirect
-    github.com/sourcegraph/conc v0.3.0 // indirect
-    github.com/spf13/afero v1.11.0 // indirect
-    github.com/spf13/cast v1.6.0 // indirect
-    github.com/spf13/pflag v1.0.5 // indirect
-    github.com/subosito/gotenv v1.6.0 // indirect
-    github.com/valyala/bytebufferpool v1.0.0 // indirect
-    github.com/valyala/fasttemplate v1.2.2 // indirect
-    go.uber.org/atomic v1.9.0 // indirect
-    go.uber.org/multierr v1.9.0 // indirect
-    golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
-    golang.org/x/net v0.27.0 // indirect
-    golang.org/x/sys v0.22.0 // indirect
-    golang.org/x/text v0.16.0 // indirect
-    golang.org/x/time v0.5.0 // indirect
-    gopkg.in/ini.v1 v1.67.0 // indirect
-    gopkg.in/yaml.v3 v3.0.1 // indirect
-)
\ No newline at end of file
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/magiconair/properties v1.8.7 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/pelletier/go-toml/v2 v2.2.2 // indirect
+	github.com/sagikazarmark/locafero v0.4.0 // indirect
+	github.com/sagikazarmark/slog-shim v0.1.0 // indirect
+	github.com/sourcegraph/conc v0.3.0 // indirect
+	github.com/spf13/afero v1.11.0 // indirect
+	github.com/spf13/cast v1.6.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/subosito/gotenv v1.6.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	go.uber.org/atomic v1.9.0 // indirect
+	go.uber.org/multierr v1.9.0 // indirect
+	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
+	golang.org/x/net v0.42.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
+	golang.org/x/time v0.5.0 // indirect
+	gopkg.in/ini.v1 v1.67.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
@@ -0,0 +1,17 @@
+package domain
+
+// CacheChannel - канал Postgres LISTEN/NOTIFY для уведомлений об изменении экземпляров и шаблонов.
+// Уведомление отправляет репозиторий после UpdateInstance, DeleteInstance, UpdateTemplate и DeleteTemplate,
+// слушает - кэш вебхуков на каждой реплике.
+const CacheChannel = "bridge_cache"
+
+// Виды уведомлений кэша: полезная нагрузка имеет вид <вид>:<ID>
+const (
+	CacheKindInstance = "instance"
+	CacheKindTemplate = "template"
+)
+
+// CachePayload формирует полезную нагрузку уведомления кэша
+func CachePayload(kind, id string) string {
+	return kind + ":" + id
+}
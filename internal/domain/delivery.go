@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы сообщений в очереди отправки
+const (
+	OutboxStatusPending = "pending" // ожидает отправки (в том числе повторной)
+	OutboxStatusSending = "sending" // захвачено воркером
+	OutboxStatusDead    = "dead"    // исчерпаны попытки доставки
+)
+
+// OutboxMessage - сообщение в очереди отправки (таблица delivery_outbox).
+// Сообщения в статусе dead образуют очередь недоставленных (dead-letter) и хранятся до повторной отправки или удаления.
+type OutboxMessage struct {
+	ID             int64          `db:"id" json:"id"`
+	InstanceID     string         `db:"instance_id" json:"instance_id"`
+	ChatID         string         `db:"chat_id" json:"chat_id"`
+	Login          string         `db:"login" json:"login,omitempty"` // получатель личного сообщения вместо чата
+	Message        string         `db:"message" json:"message"`
+	Options        MessageOptions `db:"options" json:"options"`
+	CorrelationKey string         `db:"correlation_key" json:"correlation_key,omitempty"`
+	CloseThread    bool           `db:"close_thread" json:"close_thread,omitempty"` // после отправки удалить связь ключа с сообщением
+	Progress       SendProgress   `db:"progress" json:"progress"` // части, отправленные предыдущими попытками
+	Status         string         `db:"status" json:"status"`
+	Attempts       int            `db:"attempts" json:"attempts"`
+	MaxAttempts    int            `db:"max_attempts" json:"max_attempts"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError      *string        `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// Recipient возвращает получателя сообщения: ID чата или login:<логин> для личных сообщений
+func (m *OutboxMessage) Recipient() string {
+	if m.Login != "" {
+		return "login:" + m.Login
+	}
+	return m.ChatID
+}
+
+// MessageThread - связь ключа корреляции с отправленным сообщением (таблица message_threads)
+type MessageThread struct {
+	InstanceID     string    `db:"instance_id" json:"instance_id"`
+	CorrelationKey string    `db:"correlation_key" json:"correlation_key"`
+	ChatID         string    `db:"chat_id" json:"chat_id"`
+	MessageID      int64     `db:"message_id" json:"message_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	ExpiresAt      time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// Типы записей истории доставок
+const (
+	DeliveryKindReceived = "received" // входящий вебхук
+	DeliveryKindAttempt  = "attempt"  // попытка отправки в мессенджер
+)
+
+// Статусы записей истории доставок
+const (
+	DeliveryStatusAccepted   = "accepted"   // вебхук принят, сообщение поставлено в очередь
+	DeliveryStatusInactive   = "inactive"   // экземпляр выключен
+	DeliveryStatusRejected   = "rejected"   // запрос не прошёл проверку подписи или секрета
+	DeliveryStatusSuppressed = "suppressed" // повтор уведомления Alertmanager без изменений
+	DeliveryStatusDuplicate  = "duplicate"  // повторная доставка того же вебхука в окне дедупликации
+	DeliveryStatusDropped    = "dropped"    // событие отброшено правилом фильтрации или шаблон дал пустой результат
+	DeliveryStatusError      = "error"      // ошибка разбора или рендеринга
+	DeliveryStatusSent       = "sent"       // сообщение доставлено
+	DeliveryStatusFailed     = "failed"     // попытка не удалась, будет повтор
+	DeliveryStatusDead       = "dead"       // попытка не удалась, повторов больше не будет
+)
+
+// DeliveryLog - запись истории доставок
+type DeliveryLog struct {
+	ID             int64           `db:"id" json:"id"`
+	InstanceID     string          `db:"instance_id" json:"instance_id"`
+	OutboxID       *int64          `db:"outbox_id" json:"outbox_id,omitempty"`
+	Kind           string          `db:"kind" json:"kind"`
+	Status         string          `db:"status" json:"status"`
+	RequestHeaders json.RawMessage `db:"request_headers" json:"request_headers,omitempty"`
+	RequestPayload *string         `db:"request_payload" json:"request_payload,omitempty"`
+	RenderedText   *string         `db:"rendered_text" json:"rendered_text,omitempty"`
+	ResponseStatus *int            `db:"response_status" json:"response_status,omitempty"`
+	ResponseBody   *string         `db:"response_body" json:"response_body,omitempty"`
+	Error          *string         `db:"error" json:"error,omitempty"`
+	DurationMS     int             `db:"duration_ms" json:"duration_ms"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// DeliveryLogFilter - параметры выборки истории доставок
+type DeliveryLogFilter struct {
+	Kind   string
+	Status string
+	Limit  int
+	Offset int
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -43,30 +44,24 @@ type DestinationConfig struct {
 	BotToken string `json:"bot_token" db:"bot_token"`
 }
 
-//// DeliveryLog - лог доставки
-//type DeliveryLog struct {
-//	ID             int64           `db:"id" json:"id"`
-//	IntegrationID  string          `db:"integration_id" json:"integration_id"`
-//	SourceEventID  string          `db:"source_event_id" json:"source_event_id"`
-//	RequestPayload json.RawMessage `db:"request_payload" json:"request_payload"`
-//	ResponseStatus int             `db:"response_status" json:"response_status"`
-//	ResponseBody   json.RawMessage `db:"response_body" json:"response_body"`
-//	Error          string          `db:"error" json:"error"`
-//	DeliveredAt    time.Time       `db:"delivered_at" json:"delivered_at"`
-//	DurationMS     int             `db:"duration_ms" json:"duration_ms"`
-//}
-
-// APIKey - ключ для доступа к API
+// APIKey - ключ для доступа к API. Сам ключ показывается один раз, хранится только его хеш.
 type APIKey struct {
 	ID         string     `db:"id" json:"id"`
 	UserID     string     `db:"user_id" json:"user_id"`
 	KeyHash    string     `db:"key_hash" json:"-"`
+	KeyPrefix  string     `db:"key_prefix" json:"key_prefix"` // начало ключа для узнавания в списке
 	Name       string     `db:"name" json:"name"`
+	ReadOnly   bool       `db:"read_only" json:"read_only"`
 	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
 	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
 	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
 }
 
+// IsExpired сообщает, истёк ли срок действия ключа
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
 // Template - постоянный шаблон интеграции
 type Template struct {
 	ID            string          `db:"id" json:"id"`
@@ -76,12 +71,111 @@ type Template struct {
 	TemplateText  string          `db:"template_text" json:"template_text"`
 	IsPublic      bool            `db:"is_public" json:"is_public"`
 	CreatedBy     sql.NullString  `db:"created_by" json:"created_by,omitempty"`
-	SamplePayload json.RawMessage `db:"sample_payload" json:"sample_payload,omitempty"`
-	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	SamplePayload json.RawMessage `db:"sample_payload" json:"sample_payload,omitempty"` // устаревшее поле, примеры хранятся в template_samples
+	Tests         []TemplateTest  `db:"tests" json:"tests,omitempty"`
+	Version       int             `db:"version" json:"version"` // номер последней версии (при закреплении - закреплённой)
+
+	// Личная копия общего шаблона: исходный шаблон и его версия на момент копирования
+	ForkedFrom        *string   `db:"forked_from" json:"forked_from,omitempty"`
+	ForkedFromVersion *int      `db:"forked_from_version" json:"forked_from_version,omitempty"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
 
 	// Для обратной совместимости со старой структурой
 	IntegrationID *string `db:"integration_id" json:"integration_id,omitempty"`
+
+	// Примеры данных; заполняются отдельно через ListTemplateSamples
+	Samples []*TemplateSample `db:"-" json:"samples,omitempty"`
+
+	// Автор и комментарий изменения для истории версий при сохранении; в templates не хранятся
+	ChangeAuthor string `db:"-" json:"-"`
+	ChangeNote   string `db:"-" json:"-"`
+}
+
+// TemplateVersion - неизменяемый снимок шаблона (таблица template_versions)
+type TemplateVersion struct {
+	ID           string         `db:"id" json:"id"`
+	TemplateID   string         `db:"template_id" json:"template_id"`
+	Version      int            `db:"version" json:"version"`
+	Name         string         `db:"name" json:"name"`
+	Description  string         `db:"description" json:"description,omitempty"`
+	Icon         string         `db:"icon" json:"icon,omitempty"`
+	TemplateText string         `db:"template_text" json:"template_text"`
+	Tests        []TemplateTest `db:"-" json:"tests,omitempty"`
+	AuthorID     *string        `db:"author_id" json:"author_id,omitempty"`
+	AuthorEmail  *string        `db:"author_email" json:"author_email,omitempty"`
+	Note         string         `db:"note" json:"note,omitempty"`
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+}
+
+// CatalogTemplate - шаблон встроенного каталога, установленный в базу (таблица catalog_templates).
+// TemplateVersion - версия шаблона, записанная каталогом: если шаблон после неё правили, обновления каталога не применяются.
+type CatalogTemplate struct {
+	Key             string    `db:"key" json:"key"`
+	TemplateID      *string   `db:"template_id" json:"template_id,omitempty"` // nil - шаблон удалён и заново не устанавливается
+	Revision        int       `db:"revision" json:"revision"`
+	TemplateVersion int       `db:"template_version" json:"template_version"`
+	SyncedAt        time.Time `db:"synced_at" json:"synced_at"`
+}
+
+// Fork возвращает личную копию шаблона пользователя userID с отметкой об исходном шаблоне и его версии.
+// Копия не сохранена: ID, версию и даты проставляет CreateTemplate, примеры данных копируются отдельно.
+func (t *Template) Fork(userID string) *Template {
+	forkedFrom, forkedFromVersion := t.ID, t.Version
+	return &Template{
+		Name:              t.Name + " (копия)",
+		Description:       t.Description,
+		Icon:              t.Icon,
+		TemplateText:      t.TemplateText,
+		IsPublic:          false,
+		CreatedBy:         sql.NullString{String: userID, Valid: userID != ""},
+		Tests:             t.Tests,
+		ForkedFrom:        &forkedFrom,
+		ForkedFromVersion: &forkedFromVersion,
+		ChangeAuthor:      userID,
+		ChangeNote:        fmt.Sprintf("Копия шаблона %s v%d", t.Name, t.Version),
+	}
+}
+
+// Restore переносит снимок версии в шаблон. После UpdateTemplate откат записывается новой версией.
+func (t *Template) Restore(version *TemplateVersion) {
+	t.Name = version.Name
+	t.Description = version.Description
+	t.Icon = version.Icon
+	t.TemplateText = version.TemplateText
+	t.Tests = version.Tests
+}
+
+// Способы сравнения результата теста шаблона с ожидаемым
+const (
+	TemplateTestExact    = "exact"    // текст совпадает с ожидаемым (без учёта пробелов по краям)
+	TemplateTestContains = "contains" // текст содержит ожидаемую подстроку
+	TemplateTestRegex    = "regex"    // текст соответствует регулярному выражению
+)
+
+// TemplateTest - тест шаблона: входные данные и ожидаемый текст сообщения.
+// Данные берутся из примера шаблона Sample или из Payload с заголовками Headers.
+// Пустой Expected при точном сравнении означает, что сообщение не должно отправляться.
+type TemplateTest struct {
+	Name     string            `json:"name"`
+	Sample   string            `json:"sample,omitempty"`
+	Payload  json.RawMessage   `json:"payload,omitempty"` // JSON-значение или строка (форма, XML, текст)
+	Headers  map[string]string `json:"headers,omitempty"`
+	Match    string            `json:"match,omitempty"` // exact (по умолчанию), contains, regex
+	Expected string            `json:"expected"`
+}
+
+// TemplateSample - именованный пример входящих данных шаблона (таблица template_samples).
+// Headers и Payload хранятся так же, как last_webhook_headers и last_webhook_body экземпляра.
+type TemplateSample struct {
+	ID          string          `db:"id" json:"id"`
+	TemplateID  string          `db:"template_id" json:"template_id"`
+	Name        string          `db:"name" json:"name"`
+	Description string          `db:"description" json:"description,omitempty"`
+	Headers     json.RawMessage `db:"headers" json:"headers,omitempty"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
 }
 
 // IntegrationInstance - экземпляр интеграции (использование шаблона)
@@ -95,11 +189,18 @@ type IntegrationInstance struct {
 	IsActive       bool                   `db:"is_active" json:"is_active"`
 	CustomSettings map[string]interface{} `db:"custom_settings" json:"custom_settings"`
 
+	// Закреплённая версия шаблона; nil - всегда последняя
+	TemplateVersion *int `db:"template_version" json:"template_version,omitempty"`
+
 	// Новые поля для хранения последнего вебхука
 	LastWebhookHeaders json.RawMessage `db:"last_webhook_headers" json:"last_webhook_headers,omitempty"`
 	LastWebhookBody    json.RawMessage `db:"last_webhook_body" json:"last_webhook_body,omitempty"`
 	LastWebhookAt      *time.Time      `db:"last_webhook_at" json:"last_webhook_at,omitempty"`
 
+	// Количество недоставленных сообщений и отфильтрованных событий (заполняются в ListInstances)
+	DeadLetterCount int `db:"-" json:"dead_letter_count"`
+	DroppedCount    int `db:"-" json:"dropped_count"`
+
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageOptions - параметры сообщения помимо текста.
+// Задаются во front matter шаблона и хранятся в delivery_outbox.options (JSONB).
+type MessageOptions struct {
+	Buttons               []MessageButton `json:"buttons,omitempty" yaml:"buttons"`
+	ReplyMessageID        int64           `json:"reply_message_id,omitempty" yaml:"reply_message_id"`
+	ThreadID              int64           `json:"thread_id,omitempty" yaml:"thread_id"`
+	DisableWebPagePreview bool            `json:"disable_web_page_preview,omitempty" yaml:"disable_web_page_preview"`
+	ImageURL              string          `json:"image_url,omitempty" yaml:"image_url"` // изображение скачивается и отправляется через sendImage
+	FileURL               string          `json:"file_url,omitempty" yaml:"file_url"`   // файл скачивается и отправляется через sendFile
+	FileName              string          `json:"file_name,omitempty" yaml:"file_name"`
+}
+
+// MessageButton - кнопка inline-клавиатуры
+type MessageButton struct {
+	Text         string                 `json:"text" yaml:"text"`
+	URL          string                 `json:"url,omitempty" yaml:"url"`
+	CallbackData map[string]interface{} `json:"callback_data,omitempty" yaml:"callback_data"`
+}
+
+// IsEmpty сообщает, что никаких параметров не задано
+func (o MessageOptions) IsEmpty() bool {
+	return len(o.Buttons) == 0 &&
+		o.ReplyMessageID == 0 &&
+		o.ThreadID == 0 &&
+		!o.DisableWebPagePreview &&
+		o.ImageURL == "" &&
+		o.FileURL == "" &&
+		o.FileName == ""
+}
+
+// Value сохраняет параметры в JSONB (NULL, если параметров нет)
+func (o MessageOptions) Value() (driver.Value, error) {
+	if o.IsEmpty() {
+		return nil, nil
+	}
+	return json.Marshal(o)
+}
+
+// Scan читает параметры из JSONB
+func (o *MessageOptions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*o = MessageOptions{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, o)
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	default:
+		return fmt.Errorf("unsupported type for MessageOptions: %T", src)
+	}
+}
+
+// SendProgress - части сообщения, уже отправленные в мессенджер, с ID отправленных сообщений.
+// Хранится в delivery_outbox.progress (JSONB): повторная попытка отправляет только оставшиеся части.
+type SendProgress struct {
+	TextMessageID  int64 `json:"text_message_id,omitempty"`
+	ImageMessageID int64 `json:"image_message_id,omitempty"`
+	FileMessageID  int64 `json:"file_message_id,omitempty"`
+}
+
+// IsEmpty сообщает, что ни одна часть сообщения ещё не отправлена
+func (p SendProgress) IsEmpty() bool {
+	return p.TextMessageID == 0 && p.ImageMessageID == 0 && p.FileMessageID == 0
+}
+
+// FirstMessageID возвращает ID первой отправленной части: текст, затем изображение, затем файл
+func (p SendProgress) FirstMessageID() int64 {
+	switch {
+	case p.TextMessageID != 0:
+		return p.TextMessageID
+	case p.ImageMessageID != 0:
+		return p.ImageMessageID
+	default:
+		return p.FileMessageID
+	}
+}
+
+// Value сохраняет прогресс в JSONB (NULL, если ничего не отправлено)
+func (p SendProgress) Value() (driver.Value, error) {
+	if p.IsEmpty() {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan читает прогресс из JSONB
+func (p *SendProgress) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = SendProgress{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("unsupported type for SendProgress: %T", src)
+	}
+}
@@ -19,9 +19,12 @@ type IntegrationRepository interface {
 	FindAll(ctx context.Context) ([]*domain.Integration, error)
 	FindByIDAndUser(ctx context.Context, id string, userID string) (*domain.Integration, error)
 
-	// Логи
-	//CreateDeliveryLog(ctx context.Context, log *domain.DeliveryLog) error
-	//GetDeliveryLogs(ctx context.Context, integrationID string, userID string, limit, offset int) ([]*domain.DeliveryLog, int, error)
+	// История доставок
+	CreateDeliveryLog(ctx context.Context, log *domain.DeliveryLog) error
+	// GetDeliveryLogs возвращает страницу истории экземпляра пользователя и общее количество записей
+	GetDeliveryLogs(ctx context.Context, instanceID string, userID string, filter domain.DeliveryLogFilter) ([]*domain.DeliveryLog, int, error)
+	// DeleteDeliveryLogsBefore удаляет записи старше before и возвращает их количество
+	DeleteDeliveryLogsBefore(ctx context.Context, before time.Time) (int64, error)
 
 	// Пользователи
 	CreateUser(ctx context.Context, user *domain.User) error
@@ -31,6 +34,7 @@ type IntegrationRepository interface {
 	// API ключи
 	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
 	FindAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]*domain.APIKey, error)
 	UpdateAPIKeyLastUsed(ctx context.Context, id string) error
 	DeleteAPIKey(ctx context.Context, id string, userID string) error
 
@@ -42,6 +46,26 @@ type IntegrationRepository interface {
 	DeleteTemplate(ctx context.Context, id string) error
 	GetTemplateByID(ctx context.Context, id string) (*domain.Template, error)
 	ListTemplates(ctx context.Context, userID string, includePublic bool) ([]*domain.Template, error)
+	// FindTemplatesByName возвращает все шаблоны с таким названием (названия не уникальны)
+	FindTemplatesByName(ctx context.Context, name string) ([]*domain.Template, error)
+	ListAllTemplates(ctx context.Context) ([]*domain.Template, error)
+
+	// Встроенный каталог шаблонов
+	GetCatalogTemplate(ctx context.Context, key string) (*domain.CatalogTemplate, error)
+	SaveCatalogTemplate(ctx context.Context, item *domain.CatalogTemplate) error
+	// LockCatalogSync ждёт блокировку синхронизации каталога; блокировка снимается вызовом unlock
+	LockCatalogSync(ctx context.Context) (unlock func(), err error)
+
+	// Версии шаблонов: CreateTemplate и UpdateTemplate добавляют версию, история не изменяется
+	ListTemplateVersions(ctx context.Context, templateID string) ([]*domain.TemplateVersion, error)
+	GetTemplateVersion(ctx context.Context, templateID string, version int) (*domain.TemplateVersion, error)
+
+	// Примеры данных шаблонов
+	ListTemplateSamples(ctx context.Context, templateIDs ...string) ([]*domain.TemplateSample, error)
+	GetTemplateSample(ctx context.Context, templateID, id string) (*domain.TemplateSample, error)
+	// SaveTemplateSample создает пример или заменяет пример шаблона с тем же именем
+	SaveTemplateSample(ctx context.Context, sample *domain.TemplateSample) error
+	DeleteTemplateSample(ctx context.Context, templateID, id string) error
 
 	// Экземпляры
 	CreateInstance(ctx context.Context, instance *domain.IntegrationInstance) error
@@ -64,4 +88,40 @@ type IntegrationRepository interface {
 	ChangePassword(ctx context.Context, userID string, newPasswordHash string) error
 	DeleteUser(ctx context.Context, id string) error
 	AdminResetPassword(ctx context.Context, userID string, newPasswordHash string) error
+
+	// Очередь отправки (outbox)
+	// EnqueueOutbox добавляет сообщения одной транзакцией (все или ни одного)
+	EnqueueOutbox(ctx context.Context, messages []*domain.OutboxMessage) error
+	// ClaimOutbox захватывает до limit готовых к отправке сообщений (FOR UPDATE SKIP LOCKED)
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxMessage, error)
+	// SaveOutboxProgress запоминает отправленные части сообщения, чтобы повторная попытка их не дублировала
+	SaveOutboxProgress(ctx context.Context, id int64, progress domain.SendProgress) error
+	// CompleteOutbox удаляет успешно отправленное сообщение из очереди
+	CompleteOutbox(ctx context.Context, id int64) error
+	// FailOutbox возвращает сообщение в очередь на nextAttemptAt или, если nextAttemptAt == nil, помечает его как dead
+	FailOutbox(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error
+
+	// Недоставленные сообщения (строки очереди в статусе dead)
+	ListDeadLetters(ctx context.Context, instanceID string) ([]*domain.OutboxMessage, error)
+	// RedeliverDeadLetter возвращает сообщение в очередь; пустые message и chatID оставляют значения без изменений
+	RedeliverDeadLetter(ctx context.Context, instanceID string, id int64, message, chatID string) error
+	RedeliverAllDeadLetters(ctx context.Context, instanceID string, chatID string) (int64, error)
+	DiscardDeadLetter(ctx context.Context, instanceID string, id int64) error
+	DiscardAllDeadLetters(ctx context.Context, instanceID string) (int64, error)
+
+	// Связи ключа корреляции с отправленным сообщением
+	GetMessageThread(ctx context.Context, instanceID, correlationKey, chatID string) (*domain.MessageThread, error)
+	SaveMessageThread(ctx context.Context, thread *domain.MessageThread) error
+	DeleteMessageThread(ctx context.Context, instanceID, correlationKey, chatID string) error
+	DeleteExpiredMessageThreads(ctx context.Context) (int64, error)
+
+	// Состояния групп и алертов Alertmanager для отбрасывания повторов
+	TouchAlertState(ctx context.Context, instanceID, stateKey, hash string, expiresAt time.Time) (bool, error)
+	DeleteAlertState(ctx context.Context, instanceID, stateKey string) error
+	DeleteExpiredAlertStates(ctx context.Context) (int64, error)
+
+	// Ключи идемпотентности вебхуков
+	ClaimDedupKey(ctx context.Context, instanceID, key string, expiresAt time.Time) (bool, error)
+	ReleaseDedupKey(ctx context.Context, instanceID, key string) error
+	DeleteExpiredDedupKeys(ctx context.Context) (int64, error)
 }
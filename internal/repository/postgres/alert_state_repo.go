@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// ================ МЕТОДЫ ДЛЯ СОСТОЯНИЙ ALERTMANAGER ================
+
+// TouchAlertState сохраняет отпечаток состояния и продлевает его срок.
+// Возвращает false, если действующее состояние уже имеет тот же отпечаток (повтор без изменений).
+func (r *IntegrationRepository) TouchAlertState(ctx context.Context, instanceID, stateKey, hash string, expiresAt time.Time) (bool, error) {
+	// NOW() постоянен в пределах транзакции: updated_at = NOW() только у новой или изменившейся строки
+	query := `
+        INSERT INTO alert_states (instance_id, state_key, hash, updated_at, expires_at)
+        VALUES ($1, $2, $3, NOW(), $4)
+        ON CONFLICT (instance_id, state_key) DO UPDATE
+        SET hash = EXCLUDED.hash,
+            updated_at = CASE
+                WHEN alert_states.hash <> EXCLUDED.hash OR alert_states.expires_at <= NOW() THEN NOW()
+                ELSE alert_states.updated_at
+            END,
+            expires_at = EXCLUDED.expires_at
+        RETURNING updated_at = NOW()
+    `
+
+	var changed bool
+	if err := r.db.QueryRowContext(ctx, query, instanceID, stateKey, hash, expiresAt).Scan(&changed); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+// DeleteAlertState удаляет состояние, чтобы следующее уведомление было отправлено
+func (r *IntegrationRepository) DeleteAlertState(ctx context.Context, instanceID, stateKey string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM alert_states WHERE instance_id = $1 AND state_key = $2`, instanceID, stateKey)
+	return err
+}
+
+// DeleteExpiredAlertStates удаляет состояния с истёкшим сроком
+func (r *IntegrationRepository) DeleteExpiredAlertStates(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM alert_states WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
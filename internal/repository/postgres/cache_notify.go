@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ УВЕДОМЛЕНИЯ КЭША ВЕБХУКОВ ================
+
+// notifyCache сообщает всем репликам (LISTEN/NOTIFY), что экземпляр или шаблон изменился
+// и его нужно сбросить из кэша вебхуков. Ошибка не прерывает изменение: записи кэша истекут по TTL.
+func (r *IntegrationRepository) notifyCache(ctx context.Context, kind, id string) {
+	if _, err := r.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", domain.CacheChannel, domain.CachePayload(kind, id)); err != nil {
+		log.Warn().Err(err).Str("kind", kind).Str("id", id).Msg("Failed to notify cache invalidation")
+	}
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ МЕТОДЫ ДЛЯ КАТАЛОГА ШАБЛОНОВ ================
+
+// catalogSyncLockID - ключ advisory-блокировки синхронизации каталога
+const catalogSyncLockID = 0x63617461
+
+// GetCatalogTemplate возвращает установленный шаблон каталога по ключу (sql.ErrNoRows, если его не устанавливали)
+func (r *IntegrationRepository) GetCatalogTemplate(ctx context.Context, key string) (*domain.CatalogTemplate, error) {
+	query := `
+        SELECT key, template_id, revision, template_version, synced_at
+        FROM catalog_templates
+        WHERE key = $1
+    `
+
+	var item domain.CatalogTemplate
+	if err := r.db.GetContext(ctx, &item, query, key); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// SaveCatalogTemplate записывает установленную или обновлённую ревизию шаблона каталога
+func (r *IntegrationRepository) SaveCatalogTemplate(ctx context.Context, item *domain.CatalogTemplate) error {
+	query := `
+        INSERT INTO catalog_templates (key, template_id, revision, template_version, synced_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        ON CONFLICT (key) DO UPDATE
+        SET template_id = EXCLUDED.template_id, revision = EXCLUDED.revision,
+            template_version = EXCLUDED.template_version, synced_at = NOW()
+        RETURNING synced_at
+    `
+
+	return r.db.QueryRowContext(ctx, query,
+		item.Key,
+		item.TemplateID,
+		item.Revision,
+		item.TemplateVersion,
+	).Scan(&item.SyncedAt)
+}
+
+// LockCatalogSync ждёт advisory-блокировку синхронизации каталога, чтобы реплики не устанавливали
+// одни и те же шаблоны одновременно. Блокировка держится на отдельном соединении до вызова unlock.
+func (r *IntegrationRepository) LockCatalogSync(ctx context.Context) (func(), error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, catalogSyncLockID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, catalogSyncLockID)
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ================ МЕТОДЫ ДЛЯ ДЕДУПЛИКАЦИИ ВЕБХУКОВ ================
+
+// ClaimDedupKey занимает ключ идемпотентности до expiresAt.
+// Возвращает false, если ключ уже занят и окно ещё не истекло (дубль).
+func (r *IntegrationRepository) ClaimDedupKey(ctx context.Context, instanceID, key string, expiresAt time.Time) (bool, error) {
+	query := `
+        INSERT INTO webhook_dedup (instance_id, dedup_key, first_seen_at, expires_at)
+        VALUES ($1, $2, NOW(), $3)
+        ON CONFLICT (instance_id, dedup_key) DO UPDATE
+        SET first_seen_at = NOW(), expires_at = EXCLUDED.expires_at
+        WHERE webhook_dedup.expires_at <= NOW()
+        RETURNING true
+    `
+
+	var claimed bool
+	err := r.db.QueryRowContext(ctx, query, instanceID, key, expiresAt).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+// ReleaseDedupKey освобождает ключ, чтобы повторная доставка вебхука была обработана
+func (r *IntegrationRepository) ReleaseDedupKey(ctx context.Context, instanceID, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhook_dedup WHERE instance_id = $1 AND dedup_key = $2`, instanceID, key)
+	return err
+}
+
+// DeleteExpiredDedupKeys удаляет ключи с истёкшим окном
+func (r *IntegrationRepository) DeleteExpiredDedupKeys(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_dedup WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
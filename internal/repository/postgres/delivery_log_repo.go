@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ МЕТОДЫ ДЛЯ ИСТОРИИ ДОСТАВОК ================
+
+// CreateDeliveryLog сохраняет запись истории доставок
+func (r *IntegrationRepository) CreateDeliveryLog(ctx context.Context, log *domain.DeliveryLog) error {
+	query := `
+        INSERT INTO delivery_logs (instance_id, outbox_id, kind, status, request_headers, request_payload,
+                                   rendered_text, response_status, response_body, error, duration_ms, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+        RETURNING id, created_at
+    `
+
+	// Пустой json.RawMessage нельзя записать в JSONB
+	var headers interface{}
+	if len(log.RequestHeaders) > 0 {
+		headers = log.RequestHeaders
+	}
+
+	return r.db.QueryRowContext(ctx, query,
+		log.InstanceID,
+		log.OutboxID,
+		log.Kind,
+		log.Status,
+		headers,
+		log.RequestPayload,
+		log.RenderedText,
+		log.ResponseStatus,
+		log.ResponseBody,
+		log.Error,
+		log.DurationMS,
+	).Scan(&log.ID, &log.CreatedAt)
+}
+
+// GetDeliveryLogs возвращает историю доставок экземпляра с учётом владельца
+func (r *IntegrationRepository) GetDeliveryLogs(ctx context.Context, instanceID string, userID string, filter domain.DeliveryLogFilter) ([]*domain.DeliveryLog, int, error) {
+	conditions := []string{
+		"l.instance_id = $1",
+		"i.user_id = $2",
+	}
+	args := []interface{}{instanceID, userID}
+
+	if filter.Kind != "" {
+		args = append(args, filter.Kind)
+		conditions = append(conditions, fmt.Sprintf("l.kind = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("l.status = $%d", len(args)))
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := `
+        SELECT COUNT(*)
+        FROM delivery_logs l
+        JOIN integration_instances i ON i.id = l.instance_id
+        WHERE ` + where
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := fmt.Sprintf(`
+        SELECT l.id, l.instance_id, l.outbox_id, l.kind, l.status, l.request_headers, l.request_payload,
+               l.rendered_text, l.response_status, l.response_body, l.error, l.duration_ms, l.created_at
+        FROM delivery_logs l
+        JOIN integration_instances i ON i.id = l.instance_id
+        WHERE %s
+        ORDER BY l.created_at DESC, l.id DESC
+        LIMIT $%d OFFSET $%d
+    `, where, len(args)-1, len(args))
+
+	var logs []*domain.DeliveryLog
+	if err := r.db.SelectContext(ctx, &logs, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// DeleteDeliveryLogsBefore удаляет устаревшие записи истории
+func (r *IntegrationRepository) DeleteDeliveryLogsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM delivery_logs WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -418,15 +418,17 @@ func (r *IntegrationRepository) AdminResetPassword(ctx context.Context, userID s
 // CreateAPIKey создает API ключ
 func (r *IntegrationRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
 	query := `
-        INSERT INTO api_keys (id, user_id, key_hash, name, last_used_at, created_at, expires_at)
-        VALUES (gen_random_uuid(), $1, $2, $3, $4, NOW(), $5)
+        INSERT INTO api_keys (id, user_id, key_hash, key_prefix, name, read_only, last_used_at, created_at, expires_at)
+        VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW(), $7)
         RETURNING id, created_at
     `
 
 	return r.db.QueryRowContext(ctx, query,
 		key.UserID,
 		key.KeyHash,
+		key.KeyPrefix,
 		key.Name,
+		key.ReadOnly,
 		key.LastUsedAt,
 		key.ExpiresAt,
 	).Scan(&key.ID, &key.CreatedAt)
@@ -437,7 +439,7 @@ func (r *IntegrationRepository) FindAPIKeyByHash(ctx context.Context, hash strin
 	var key domain.APIKey
 
 	query := `
-        SELECT id, user_id, key_hash, name, last_used_at, created_at, expires_at
+        SELECT id, user_id, key_hash, key_prefix, name, read_only, last_used_at, created_at, expires_at
         FROM api_keys
         WHERE key_hash = $1
     `
@@ -450,6 +452,24 @@ func (r *IntegrationRepository) FindAPIKeyByHash(ctx context.Context, hash strin
 	return &key, nil
 }
 
+// ListAPIKeys возвращает API ключи пользователя
+func (r *IntegrationRepository) ListAPIKeys(ctx context.Context, userID string) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+
+	query := `
+        SELECT id, user_id, key_hash, key_prefix, name, read_only, last_used_at, created_at, expires_at
+        FROM api_keys
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `
+
+	if err := r.db.SelectContext(ctx, &keys, query, userID); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
 // UpdateAPIKeyLastUsed обновляет время использования ключа
 func (r *IntegrationRepository) UpdateAPIKeyLastUsed(ctx context.Context, id string) error {
 	query := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`
@@ -479,14 +499,26 @@ func (r *IntegrationRepository) DeleteAPIKey(ctx context.Context, id string, use
 
 // ================ МЕТОДЫ ДЛЯ ШАБЛОНОВ ================
 
-// CreateTemplate создает новый шаблон
+// CreateTemplate создает новый шаблон и его первую версию
 func (r *IntegrationRepository) CreateTemplate(ctx context.Context, template *domain.Template) error {
 	query := `
-        INSERT INTO templates (id, name, description, icon, template_text, is_public, created_by, sample_payload, created_at, updated_at)
-        VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
-        RETURNING id, created_at, updated_at
+        WITH created AS (
+            INSERT INTO templates (id, name, description, icon, template_text, is_public, created_by, sample_payload, tests,
+                                   forked_from, forked_from_version, version, created_at, updated_at)
+            VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $11, $12, 1, NOW(), NOW())
+            RETURNING id, name, description, icon, template_text, tests, version, created_at, updated_at
+        ), history AS (
+            INSERT INTO template_versions (template_id, version, name, description, icon, template_text, tests, author_id, note, created_at)
+            SELECT id, version, name, COALESCE(description, ''), COALESCE(icon, ''), template_text, tests, $9, $10, updated_at FROM created
+        )
+        SELECT id, version, created_at, updated_at FROM created
     `
 
+	tests, err := marshalTemplateTests(template.Tests)
+	if err != nil {
+		return err
+	}
+
 	return r.db.QueryRowContext(ctx, query,
 		template.Name,
 		template.Description,
@@ -495,37 +527,52 @@ func (r *IntegrationRepository) CreateTemplate(ctx context.Context, template *do
 		template.IsPublic,
 		template.CreatedBy,
 		nil,
-	).Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+		tests,
+		changeAuthor(template),
+		template.ChangeNote,
+		template.ForkedFrom,
+		template.ForkedFromVersion,
+	).Scan(&template.ID, &template.Version, &template.CreatedAt, &template.UpdatedAt)
 }
 
-// UpdateTemplate обновляет шаблон
+// UpdateTemplate обновляет шаблон и записывает новую версию в историю
+// с автором и комментарием из template.ChangeAuthor и template.ChangeNote
 func (r *IntegrationRepository) UpdateTemplate(ctx context.Context, template *domain.Template) error {
 	query := `
-        UPDATE templates 
-        SET name = $1, description = $2, icon = $3, template_text = $4, 
-            is_public = $5, updated_at = NOW()
-        WHERE id = $6
+        WITH updated AS (
+            UPDATE templates 
+            SET name = $1, description = $2, icon = $3, template_text = $4, 
+                is_public = $5, tests = $6, version = version + 1, updated_at = NOW()
+            WHERE id = $7
+            RETURNING id, name, description, icon, template_text, tests, version, updated_at
+        ), history AS (
+            INSERT INTO template_versions (template_id, version, name, description, icon, template_text, tests, author_id, note, created_at)
+            SELECT id, version, name, COALESCE(description, ''), COALESCE(icon, ''), template_text, tests, $8, $9, updated_at FROM updated
+        )
+        SELECT version, updated_at FROM updated
     `
 
-	result, err := r.db.ExecContext(ctx, query,
+	tests, err := marshalTemplateTests(template.Tests)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRowContext(ctx, query,
 		template.Name,
 		template.Description,
 		template.Icon,
 		template.TemplateText,
 		template.IsPublic,
+		tests,
 		template.ID,
-	)
+		changeAuthor(template),
+		template.ChangeNote,
+	).Scan(&template.Version, &template.UpdatedAt)
 	if err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return sql.ErrNoRows
-	}
+	r.notifyCache(ctx, domain.CacheKindTemplate, template.ID)
 
 	return nil
 }
@@ -547,17 +594,20 @@ func (r *IntegrationRepository) DeleteTemplate(ctx context.Context, id string) e
 		return sql.ErrNoRows
 	}
 
+	r.notifyCache(ctx, domain.CacheKindTemplate, id)
+
 	return nil
 }
 
 // GetTemplateByID получает шаблон по ID
 func (r *IntegrationRepository) GetTemplateByID(ctx context.Context, id string) (*domain.Template, error) {
 	var template domain.Template
-	var samplePayload []byte
+	var samplePayload, tests []byte
 	var integrationID sql.NullString
 
 	query := `
-        SELECT id, name, description, icon, template_text, is_public, created_by, sample_payload, created_at, updated_at, integration_id
+        SELECT id, name, description, icon, template_text, is_public, created_by, sample_payload, tests, version,
+               forked_from, forked_from_version, created_at, updated_at, integration_id
         FROM templates
         WHERE id = $1
     `
@@ -571,6 +621,10 @@ func (r *IntegrationRepository) GetTemplateByID(ctx context.Context, id string)
 		&template.IsPublic,
 		&template.CreatedBy,
 		&samplePayload,
+		&tests,
+		&template.Version,
+		&template.ForkedFrom,
+		&template.ForkedFromVersion,
 		&template.CreatedAt,
 		&template.UpdatedAt,
 		&integrationID,
@@ -582,6 +636,9 @@ func (r *IntegrationRepository) GetTemplateByID(ctx context.Context, id string)
 	if samplePayload != nil {
 		template.SamplePayload = samplePayload
 	}
+	if err := json.Unmarshal(tests, &template.Tests); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template tests: %w", err)
+	}
 
 	if integrationID.Valid {
 		template.IntegrationID = &integrationID.String
@@ -590,12 +647,30 @@ func (r *IntegrationRepository) GetTemplateByID(ctx context.Context, id string)
 	return &template, nil
 }
 
+// changeAuthor возвращает автора изменения шаблона для template_versions.author_id
+func changeAuthor(template *domain.Template) sql.NullString {
+	return sql.NullString{String: template.ChangeAuthor, Valid: template.ChangeAuthor != ""}
+}
+
+// marshalTemplateTests сериализует тесты шаблона для колонки tests (пустой список - [])
+func marshalTemplateTests(tests []domain.TemplateTest) ([]byte, error) {
+	if tests == nil {
+		tests = []domain.TemplateTest{}
+	}
+	data, err := json.Marshal(tests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal template tests: %w", err)
+	}
+	return data, nil
+}
+
 // ListTemplates возвращает список доступных шаблонов
 func (r *IntegrationRepository) ListTemplates(ctx context.Context, userID string, includePublic bool) ([]*domain.Template, error) {
 	var templates []*domain.Template
 
 	query := `
-        SELECT id, name, description, icon, template_text, is_public, created_by, sample_payload, created_at, updated_at, integration_id
+        SELECT id, name, description, icon, template_text, is_public, created_by, sample_payload, tests, version,
+               forked_from, forked_from_version, created_at, updated_at, integration_id
         FROM templates
         WHERE created_by = $1 OR (is_public = true AND $2 = true)
         ORDER BY name
@@ -609,7 +684,7 @@ func (r *IntegrationRepository) ListTemplates(ctx context.Context, userID string
 
 	for rows.Next() {
 		var template domain.Template
-		var samplePayload []byte
+		var samplePayload, tests []byte
 		var integrationID sql.NullString
 
 		err := rows.Scan(
@@ -621,6 +696,10 @@ func (r *IntegrationRepository) ListTemplates(ctx context.Context, userID string
 			&template.IsPublic,
 			&template.CreatedBy,
 			&samplePayload,
+			&tests,
+			&template.Version,
+			&template.ForkedFrom,
+			&template.ForkedFromVersion,
 			&template.CreatedAt,
 			&template.UpdatedAt,
 			&integrationID,
@@ -632,6 +711,9 @@ func (r *IntegrationRepository) ListTemplates(ctx context.Context, userID string
 		if samplePayload != nil {
 			template.SamplePayload = samplePayload
 		}
+		if err := json.Unmarshal(tests, &template.Tests); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal template tests: %w", err)
+		}
 
 		if integrationID.Valid {
 			template.IntegrationID = &integrationID.String
@@ -643,6 +725,34 @@ func (r *IntegrationRepository) ListTemplates(ctx context.Context, userID string
 	return templates, nil
 }
 
+// FindTemplatesByName возвращает шаблоны с точно совпадающим названием, начиная с самого старого
+func (r *IntegrationRepository) FindTemplatesByName(ctx context.Context, name string) ([]*domain.Template, error) {
+	return r.templatesByIDs(ctx, `SELECT id FROM templates WHERE name = $1 ORDER BY created_at`, name)
+}
+
+// ListAllTemplates возвращает все шаблоны независимо от владельца (для служебных команд)
+func (r *IntegrationRepository) ListAllTemplates(ctx context.Context) ([]*domain.Template, error) {
+	return r.templatesByIDs(ctx, `SELECT id FROM templates ORDER BY name`)
+}
+
+// templatesByIDs загружает шаблоны, ID которых возвращает запрос
+func (r *IntegrationRepository) templatesByIDs(ctx context.Context, query string, args ...interface{}) ([]*domain.Template, error) {
+	var ids []string
+	if err := r.db.SelectContext(ctx, &ids, query, args...); err != nil {
+		return nil, err
+	}
+
+	templates := make([]*domain.Template, 0, len(ids))
+	for _, id := range ids {
+		template, err := r.GetTemplateByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
 // ================ МЕТОДЫ ДЛЯ ЭКЗЕМПЛЯРОВ ================
 
 // CreateInstance создает новый экземпляр интеграции
@@ -684,8 +794,8 @@ func (r *IntegrationRepository) UpdateInstance(ctx context.Context, instance *do
 
 	query := `
         UPDATE integration_instances 
-        SET name = $1, chat_id = $2, is_active = $3, custom_settings = $4, updated_at = NOW()
-        WHERE id = $5 AND user_id = $6
+        SET name = $1, chat_id = $2, is_active = $3, custom_settings = $4, template_id = $5, template_version = $6, updated_at = NOW()
+        WHERE id = $7 AND user_id = $8
     `
 
 	result, err := r.db.ExecContext(ctx, query,
@@ -693,6 +803,8 @@ func (r *IntegrationRepository) UpdateInstance(ctx context.Context, instance *do
 		instance.ChatID,
 		instance.IsActive,
 		customSettingsJSON,
+		instance.TemplateID,
+		instance.TemplateVersion,
 		instance.ID,
 		instance.UserID,
 	)
@@ -721,6 +833,8 @@ func (r *IntegrationRepository) UpdateInstance(ctx context.Context, instance *do
 		}
 	}
 
+	r.notifyCache(ctx, domain.CacheKindInstance, instance.ID)
+
 	return nil
 }
 
@@ -741,6 +855,8 @@ func (r *IntegrationRepository) DeleteInstance(ctx context.Context, id string, u
 		return sql.ErrNoRows
 	}
 
+	r.notifyCache(ctx, domain.CacheKindInstance, id)
+
 	return nil
 }
 
@@ -753,7 +869,7 @@ func (r *IntegrationRepository) GetInstanceByID(ctx context.Context, id string,
 	var lastAt sql.NullTime
 
 	query := `
-        SELECT id, template_id, user_id, name, chat_id, bot_token, is_active, custom_settings,
+        SELECT id, template_id, user_id, name, chat_id, bot_token, is_active, custom_settings, template_version,
                last_webhook_headers, last_webhook_body, last_webhook_at,
                created_at, updated_at
         FROM integration_instances
@@ -769,6 +885,7 @@ func (r *IntegrationRepository) GetInstanceByID(ctx context.Context, id string,
 		&encryptedToken,
 		&instance.IsActive,
 		&customSettings,
+		&instance.TemplateVersion,
 		&lastHeaders,
 		&lastBody,
 		&lastAt,
@@ -810,7 +927,9 @@ func (r *IntegrationRepository) ListInstances(ctx context.Context, userID string
 	var instances []*domain.IntegrationInstance
 
 	query := `
-        SELECT i.id, i.template_id, i.user_id, i.name, i.chat_id, i.is_active, i.custom_settings, i.created_at, i.updated_at,
+        SELECT i.id, i.template_id, i.user_id, i.name, i.chat_id, i.is_active, i.custom_settings, i.last_webhook_at, i.created_at, i.updated_at,
+               (SELECT COUNT(*) FROM delivery_outbox o WHERE o.instance_id = i.id AND o.status = 'dead') as dead_letters,
+               (SELECT COUNT(*) FROM delivery_logs l WHERE l.instance_id = i.id AND l.status = 'dropped') as dropped,
                t.id as template_id, t.name as template_name, t.icon, t.description, t.template_text
         FROM integration_instances i
         LEFT JOIN templates t ON i.template_id = t.id
@@ -828,6 +947,7 @@ func (r *IntegrationRepository) ListInstances(ctx context.Context, userID string
 		var instance domain.IntegrationInstance
 		var template domain.Template
 		var customSettings []byte
+		var lastAt sql.NullTime
 		var templateID, templateName, templateIcon, templateDescription, templateText sql.NullString
 
 		err := rows.Scan(
@@ -838,8 +958,11 @@ func (r *IntegrationRepository) ListInstances(ctx context.Context, userID string
 			&instance.ChatID,
 			&instance.IsActive,
 			&customSettings,
+			&lastAt,
 			&instance.CreatedAt,
 			&instance.UpdatedAt,
+			&instance.DeadLetterCount,
+			&instance.DroppedCount,
 			&templateID,
 			&templateName,
 			&templateIcon,
@@ -855,6 +978,9 @@ func (r *IntegrationRepository) ListInstances(ctx context.Context, userID string
 				return nil, fmt.Errorf("failed to unmarshal custom settings: %w", err)
 			}
 		}
+		if lastAt.Valid {
+			instance.LastWebhookAt = &lastAt.Time
+		}
 
 		// Заполняем шаблон, если он есть
 		if templateID.Valid {
@@ -885,7 +1011,7 @@ func (r *IntegrationRepository) GetInstanceByIDPublic(ctx context.Context, id st
 	var customSettings []byte
 
 	query := `
-        SELECT id, template_id, user_id, name, chat_id, bot_token, is_active, custom_settings, created_at, updated_at
+        SELECT id, template_id, user_id, name, chat_id, bot_token, is_active, custom_settings, template_version, created_at, updated_at
         FROM integration_instances
         WHERE id = $1
     `
@@ -899,6 +1025,7 @@ func (r *IntegrationRepository) GetInstanceByIDPublic(ctx context.Context, id st
 		&encryptedToken,
 		&instance.IsActive,
 		&customSettings,
+		&instance.TemplateVersion,
 		&instance.CreatedAt,
 		&instance.UpdatedAt,
 	)
@@ -948,6 +1075,20 @@ func (r *IntegrationRepository) GetInstanceWithTemplate(ctx context.Context, id
 		instance.Template = template
 	}
 
+	// Закреплённая версия заменяет текст и тесты последней версии
+	if instance.Template != nil && instance.TemplateVersion != nil {
+		version, err := r.GetTemplateVersion(ctx, template.ID, *instance.TemplateVersion)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			template.TemplateText = version.TemplateText
+			template.Tests = version.Tests
+			template.Version = version.Version
+			template.UpdatedAt = version.CreatedAt
+		}
+	}
+
 	return instance, nil
 }
 
@@ -956,10 +1097,11 @@ func (r *IntegrationRepository) GetInstanceWithTemplate(ctx context.Context, id
 // GetTemplateByIntegrationID получает шаблон по ID интеграции (старый метод)
 func (r *IntegrationRepository) GetTemplateByIntegrationID(ctx context.Context, integrationID string) (*domain.Template, error) {
 	var template domain.Template
-	var samplePayload []byte
+	var samplePayload, tests []byte
 
 	query := `
-        SELECT id, name, description, icon, template_text, is_public, created_by, sample_payload, created_at, updated_at, integration_id
+        SELECT id, name, description, icon, template_text, is_public, created_by, sample_payload, tests, version,
+               forked_from, forked_from_version, created_at, updated_at, integration_id
         FROM templates
         WHERE integration_id = $1
     `
@@ -973,6 +1115,10 @@ func (r *IntegrationRepository) GetTemplateByIntegrationID(ctx context.Context,
 		&template.IsPublic,
 		&template.CreatedBy,
 		&samplePayload,
+		&tests,
+		&template.Version,
+		&template.ForkedFrom,
+		&template.ForkedFromVersion,
 		&template.CreatedAt,
 		&template.UpdatedAt,
 		&template.IntegrationID,
@@ -984,6 +1130,9 @@ func (r *IntegrationRepository) GetTemplateByIntegrationID(ctx context.Context,
 	if samplePayload != nil {
 		template.SamplePayload = samplePayload
 	}
+	if err := json.Unmarshal(tests, &template.Tests); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template tests: %w", err)
+	}
 
 	return &template, nil
 }
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ МЕТОДЫ ДЛЯ ОЧЕРЕДИ ОТПРАВКИ ================
+
+const outboxColumns = `id, instance_id, chat_id, login, message, options, correlation_key, close_thread, progress, status, attempts, max_attempts, next_attempt_at, last_error, created_at, updated_at`
+
+// EnqueueOutbox добавляет сообщения в очередь в одной транзакции: либо все, либо ни одного.
+// Иначе при ошибке на середине ретрай вебхука поставил бы уже добавленные сообщения повторно.
+func (r *IntegrationRepository) EnqueueOutbox(ctx context.Context, messages []*domain.OutboxMessage) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO delivery_outbox (instance_id, chat_id, login, message, options, correlation_key, close_thread, status, max_attempts, next_attempt_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending', $8, NOW(), NOW(), NOW())
+        RETURNING id, status, next_attempt_at, created_at, updated_at
+    `
+	for _, msg := range messages {
+		err := tx.QueryRowContext(ctx, query,
+			msg.InstanceID,
+			msg.ChatID,
+			msg.Login,
+			msg.Message,
+			msg.Options,
+			msg.CorrelationKey,
+			msg.CloseThread,
+			msg.MaxAttempts,
+		).Scan(&msg.ID, &msg.Status, &msg.NextAttemptAt, &msg.CreatedAt, &msg.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ClaimOutbox захватывает сообщения для отправки.
+// Строки, захваченные другими репликами, пропускаются (SKIP LOCKED), а сообщения,
+// зависшие в статусе sending дольше lease (упавший под), забираются повторно.
+func (r *IntegrationRepository) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxMessage, error) {
+	query := `
+        UPDATE delivery_outbox
+        SET status = 'sending', attempts = attempts + 1,
+            locked_until = NOW() + $2::bigint * INTERVAL '1 millisecond', updated_at = NOW()
+        WHERE id IN (
+            SELECT id FROM delivery_outbox
+            WHERE (status = 'pending' AND next_attempt_at <= NOW())
+               OR (status = 'sending' AND locked_until < NOW())
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + outboxColumns
+
+	var messages []*domain.OutboxMessage
+	err := r.db.SelectContext(ctx, &messages, query, limit, lease.Milliseconds())
+	return messages, err
+}
+
+// SaveOutboxProgress запоминает отправленные части сообщения
+func (r *IntegrationRepository) SaveOutboxProgress(ctx context.Context, id int64, progress domain.SendProgress) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE delivery_outbox SET progress = $1, updated_at = NOW() WHERE id = $2`, progress, id)
+	return err
+}
+
+// CompleteOutbox удаляет отправленное сообщение
+func (r *IntegrationRepository) CompleteOutbox(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM delivery_outbox WHERE id = $1`, id)
+	return err
+}
+
+// FailOutbox фиксирует неудачную попытку отправки
+func (r *IntegrationRepository) FailOutbox(ctx context.Context, id int64, lastError string, nextAttemptAt *time.Time) error {
+	var result sql.Result
+	var err error
+
+	if nextAttemptAt == nil {
+		result, err = r.db.ExecContext(ctx, `
+            UPDATE delivery_outbox
+            SET status = 'dead', last_error = $1, locked_until = NULL, updated_at = NOW()
+            WHERE id = $2
+        `, lastError, id)
+	} else {
+		result, err = r.db.ExecContext(ctx, `
+            UPDATE delivery_outbox
+            SET status = 'pending', last_error = $1, next_attempt_at = $2, locked_until = NULL, updated_at = NOW()
+            WHERE id = $3
+        `, lastError, *nextAttemptAt, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ================ НЕДОСТАВЛЕННЫЕ СООБЩЕНИЯ ================
+
+// ListDeadLetters возвращает недоставленные сообщения экземпляра
+func (r *IntegrationRepository) ListDeadLetters(ctx context.Context, instanceID string) ([]*domain.OutboxMessage, error) {
+	query := `
+        SELECT ` + outboxColumns + `
+        FROM delivery_outbox
+        WHERE instance_id = $1 AND status = 'dead'
+        ORDER BY created_at DESC
+    `
+
+	var messages []*domain.OutboxMessage
+	err := r.db.SelectContext(ctx, &messages, query, instanceID)
+	return messages, err
+}
+
+// RedeliverDeadLetter возвращает недоставленное сообщение в очередь со сброшенным счётчиком попыток.
+// Новый чат заменяет и логин получателя личного сообщения. Новый текст или получатель сбрасывает
+// отправленные части: сообщение отправляется заново целиком.
+func (r *IntegrationRepository) RedeliverDeadLetter(ctx context.Context, instanceID string, id int64, message, chatID string) error {
+	query := `
+        UPDATE delivery_outbox
+        SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW(),
+            progress = CASE WHEN COALESCE(NULLIF($1, ''), message) = message AND $2 = '' THEN progress ELSE NULL END,
+            message = COALESCE(NULLIF($1, ''), message),
+            chat_id = COALESCE(NULLIF($2, ''), chat_id),
+            login = CASE WHEN $2 = '' THEN login ELSE '' END
+        WHERE id = $3 AND instance_id = $4 AND status = 'dead'
+    `
+
+	result, err := r.db.ExecContext(ctx, query, message, chatID, id, instanceID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RedeliverAllDeadLetters возвращает в очередь все недоставленные сообщения экземпляра
+func (r *IntegrationRepository) RedeliverAllDeadLetters(ctx context.Context, instanceID string, chatID string) (int64, error) {
+	query := `
+        UPDATE delivery_outbox
+        SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW(),
+            progress = CASE WHEN $1 = '' THEN progress ELSE NULL END,
+            chat_id = COALESCE(NULLIF($1, ''), chat_id),
+            login = CASE WHEN $1 = '' THEN login ELSE '' END
+        WHERE instance_id = $2 AND status = 'dead'
+    `
+
+	result, err := r.db.ExecContext(ctx, query, chatID, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DiscardDeadLetter удаляет недоставленное сообщение
+func (r *IntegrationRepository) DiscardDeadLetter(ctx context.Context, instanceID string, id int64) error {
+	query := `DELETE FROM delivery_outbox WHERE id = $1 AND instance_id = $2 AND status = 'dead'`
+
+	result, err := r.db.ExecContext(ctx, query, id, instanceID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DiscardAllDeadLetters удаляет все недоставленные сообщения экземпляра
+func (r *IntegrationRepository) DiscardAllDeadLetters(ctx context.Context, instanceID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM delivery_outbox WHERE instance_id = $1 AND status = 'dead'`, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ МЕТОДЫ ДЛЯ ПРИМЕРОВ ДАННЫХ ШАБЛОНОВ ================
+
+// ListTemplateSamples возвращает примеры данных указанных шаблонов, упорядоченные по шаблону и имени
+func (r *IntegrationRepository) ListTemplateSamples(ctx context.Context, templateIDs ...string) ([]*domain.TemplateSample, error) {
+	query := `
+        SELECT id, template_id, name, description, headers, payload, created_at, updated_at
+        FROM template_samples
+        WHERE template_id = ANY($1)
+        ORDER BY template_id, name
+    `
+
+	var samples []*domain.TemplateSample
+	if err := r.db.SelectContext(ctx, &samples, query, pq.Array(templateIDs)); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// GetTemplateSample возвращает пример данных шаблона (sql.ErrNoRows, если его нет)
+func (r *IntegrationRepository) GetTemplateSample(ctx context.Context, templateID, id string) (*domain.TemplateSample, error) {
+	query := `
+        SELECT id, template_id, name, description, headers, payload, created_at, updated_at
+        FROM template_samples
+        WHERE template_id = $1 AND id = $2
+    `
+
+	var sample domain.TemplateSample
+	if err := r.db.GetContext(ctx, &sample, query, templateID, id); err != nil {
+		return nil, err
+	}
+	return &sample, nil
+}
+
+// SaveTemplateSample создает пример данных или заменяет пример шаблона с тем же именем
+func (r *IntegrationRepository) SaveTemplateSample(ctx context.Context, sample *domain.TemplateSample) error {
+	query := `
+        INSERT INTO template_samples (id, template_id, name, description, headers, payload, created_at, updated_at)
+        VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW())
+        ON CONFLICT (template_id, name) DO UPDATE
+        SET description = EXCLUDED.description, headers = EXCLUDED.headers, payload = EXCLUDED.payload, updated_at = NOW()
+        RETURNING id, created_at, updated_at
+    `
+
+	var headers interface{}
+	if len(sample.Headers) > 0 {
+		headers = []byte(sample.Headers)
+	}
+
+	return r.db.QueryRowContext(ctx, query,
+		sample.TemplateID,
+		sample.Name,
+		sample.Description,
+		headers,
+		[]byte(sample.Payload),
+	).Scan(&sample.ID, &sample.CreatedAt, &sample.UpdatedAt)
+}
+
+// DeleteTemplateSample удаляет пример данных шаблона
+func (r *IntegrationRepository) DeleteTemplateSample(ctx context.Context, templateID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM template_samples WHERE template_id = $1 AND id = $2`, templateID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
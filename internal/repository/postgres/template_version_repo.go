@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ МЕТОДЫ ДЛЯ ВЕРСИЙ ШАБЛОНОВ ================
+
+// templateVersionRow - строка template_versions с тестами в исходном JSON
+type templateVersionRow struct {
+	domain.TemplateVersion
+	TestsJSON []byte `db:"tests"`
+}
+
+func (row *templateVersionRow) version() (*domain.TemplateVersion, error) {
+	version := row.TemplateVersion
+	if err := json.Unmarshal(row.TestsJSON, &version.Tests); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template tests: %w", err)
+	}
+	return &version, nil
+}
+
+const templateVersionColumns = `
+        v.id, v.template_id, v.version, v.name, v.description, v.icon, v.template_text, v.tests,
+        v.author_id, u.email AS author_email, v.note, v.created_at
+`
+
+// ListTemplateVersions возвращает историю версий шаблона, начиная с последней
+func (r *IntegrationRepository) ListTemplateVersions(ctx context.Context, templateID string) ([]*domain.TemplateVersion, error) {
+	query := `
+        SELECT ` + templateVersionColumns + `
+        FROM template_versions v
+        LEFT JOIN users u ON u.id = v.author_id
+        WHERE v.template_id = $1
+        ORDER BY v.version DESC
+    `
+
+	var rows []templateVersionRow
+	if err := r.db.SelectContext(ctx, &rows, query, templateID); err != nil {
+		return nil, err
+	}
+
+	versions := make([]*domain.TemplateVersion, 0, len(rows))
+	for i := range rows {
+		version, err := rows[i].version()
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// GetTemplateVersion возвращает версию шаблона (sql.ErrNoRows, если её нет)
+func (r *IntegrationRepository) GetTemplateVersion(ctx context.Context, templateID string, version int) (*domain.TemplateVersion, error) {
+	query := `
+        SELECT ` + templateVersionColumns + `
+        FROM template_versions v
+        LEFT JOIN users u ON u.id = v.author_id
+        WHERE v.template_id = $1 AND v.version = $2
+    `
+
+	var row templateVersionRow
+	if err := r.db.GetContext(ctx, &row, query, templateID, version); err != nil {
+		return nil, err
+	}
+	return row.version()
+}
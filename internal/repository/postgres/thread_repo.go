@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// ================ МЕТОДЫ ДЛЯ СВЯЗЕЙ КЛЮЧ → СООБЩЕНИЕ ================
+
+// GetMessageThread возвращает действующую связь ключа корреляции с сообщением (sql.ErrNoRows, если её нет)
+func (r *IntegrationRepository) GetMessageThread(ctx context.Context, instanceID, correlationKey, chatID string) (*domain.MessageThread, error) {
+	query := `
+        SELECT instance_id, correlation_key, chat_id, message_id, created_at, updated_at, expires_at
+        FROM message_threads
+        WHERE instance_id = $1 AND correlation_key = $2 AND chat_id = $3 AND expires_at > NOW()
+    `
+
+	var thread domain.MessageThread
+	if err := r.db.GetContext(ctx, &thread, query, instanceID, correlationKey, chatID); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// SaveMessageThread создает или обновляет связь ключа корреляции с сообщением
+func (r *IntegrationRepository) SaveMessageThread(ctx context.Context, thread *domain.MessageThread) error {
+	query := `
+        INSERT INTO message_threads (instance_id, correlation_key, chat_id, message_id, created_at, updated_at, expires_at)
+        VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+        ON CONFLICT (instance_id, correlation_key, chat_id) DO UPDATE
+        SET message_id = EXCLUDED.message_id, updated_at = NOW(), expires_at = EXCLUDED.expires_at
+        RETURNING created_at, updated_at
+    `
+
+	return r.db.QueryRowContext(ctx, query,
+		thread.InstanceID,
+		thread.CorrelationKey,
+		thread.ChatID,
+		thread.MessageID,
+		thread.ExpiresAt,
+	).Scan(&thread.CreatedAt, &thread.UpdatedAt)
+}
+
+// DeleteMessageThread удаляет связь ключа корреляции с сообщением: следующее событие по ключу начнёт новое сообщение
+func (r *IntegrationRepository) DeleteMessageThread(ctx context.Context, instanceID, correlationKey, chatID string) error {
+	_, err := r.db.ExecContext(ctx, `
+        DELETE FROM message_threads
+        WHERE instance_id = $1 AND correlation_key = $2 AND chat_id = $3
+    `, instanceID, correlationKey, chatID)
+	return err
+}
+
+// DeleteExpiredMessageThreads удаляет связи с истёкшим сроком
+func (r *IntegrationRepository) DeleteExpiredMessageThreads(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM message_threads WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,139 @@
+// Путь: internal/service/alertmanager/payload.go
+package alertmanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Статусы уведомлений и алертов
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Message - тело вебхука Alertmanager версии 4
+type Message struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert - отдельный алерт в уведомлении
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Event - одно сообщение, которое нужно отправить по уведомлению
+type Event struct {
+	Data     map[string]interface{} // данные для Liquid-шаблона
+	Key      string                 // ключ корреляции: к нему привязывается resolved-уведомление
+	StateKey string                 // ключ состояния для отбрасывания повторов
+	Hash     string                 // отпечаток состояния: повтор с тем же отпечатком отбрасывается
+	Final    bool                   // resolved-уведомление завершает срабатывание: после отправки связь Key с сообщением удаляется
+}
+
+// ErrNotAlertmanager - тело запроса не похоже на уведомление Alertmanager v4
+var ErrNotAlertmanager = errors.New("payload is not an alertmanager v4 notification")
+
+// Events разбивает уведомление на сообщения согласно настройкам.
+// В режиме group ключ корреляции - groupKey: resolved-уведомление группы помечается Final, и новое срабатывание
+// после него начинает новое сообщение, а не отвечает на старое.
+// В режиме alert для каждого алерта в шаблон передаётся исходное уведомление,
+// в котором alerts содержит только этот алерт, status - его статус, а alert - сам алерт.
+func (s *Settings) Events(data map[string]interface{}) ([]Event, error) {
+	msg, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Split != SplitAlert {
+		return []Event{{
+			Data:     data,
+			Key:      msg.GroupKey,
+			StateKey: msg.GroupKey,
+			Hash:     groupHash(msg),
+			Final:    msg.Status == StatusResolved,
+		}}, nil
+	}
+
+	rawAlerts, _ := data["alerts"].([]interface{})
+	events := make([]Event, 0, len(msg.Alerts))
+	for i, alert := range msg.Alerts {
+		alertData := make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			alertData[k] = v
+		}
+		alertData["alerts"] = []interface{}{rawAlerts[i]}
+		alertData["alert"] = rawAlerts[i]
+		alertData["status"] = alert.Status
+
+		// startsAt одинаков у firing и resolved одного срабатывания,
+		// поэтому новое срабатывание того же алерта начинает новое сообщение
+		events = append(events, Event{
+			Data:     alertData,
+			Key:      alert.Fingerprint + "@" + alert.StartsAt.UTC().Format(time.RFC3339),
+			StateKey: alert.Fingerprint,
+			Hash:     hash(alert.Status + "|" + alert.StartsAt.UTC().Format(time.RFC3339)),
+			Final:    alert.Status == StatusResolved,
+		})
+	}
+	return events, nil
+}
+
+// decode разбирает уведомление и проверяет обязательные поля
+func decode(data map[string]interface{}) (*Message, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotAlertmanager, err)
+	}
+	if msg.Version != "4" || msg.GroupKey == "" {
+		return nil, ErrNotAlertmanager
+	}
+	for _, alert := range msg.Alerts {
+		if alert.Fingerprint == "" {
+			return nil, fmt.Errorf("%w: alert without fingerprint", ErrNotAlertmanager)
+		}
+	}
+	return &msg, nil
+}
+
+// groupHash - отпечаток состояния группы: статус и набор алертов с их статусами.
+// Аннотации не учитываются: в них часто меняется текущее значение метрики.
+func groupHash(msg *Message) string {
+	parts := make([]string, 0, len(msg.Alerts))
+	for _, alert := range msg.Alerts {
+		parts = append(parts, alert.Fingerprint+"|"+alert.Status+"|"+alert.StartsAt.UTC().Format(time.RFC3339))
+	}
+	sort.Strings(parts)
+	return hash(msg.Status + "\n" + strings.Join(parts, "\n"))
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,64 @@
+package alertmanager
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func notification(t *testing.T, status string, alertStatuses ...string) map[string]interface{} {
+	t.Helper()
+	alerts := make([]interface{}, 0, len(alertStatuses))
+	for i, s := range alertStatuses {
+		alerts = append(alerts, map[string]interface{}{
+			"status":      s,
+			"labels":      map[string]interface{}{"alertname": "DiskFull"},
+			"startsAt":    "2024-05-01T10:00:00Z",
+			"fingerprint": string(rune('a' + i)),
+		})
+	}
+	raw, _ := json.Marshal(map[string]interface{}{
+		"version": "4", "groupKey": `{}:{alertname="DiskFull"}`, "status": status, "alerts": alerts,
+	})
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestEventsGroupFinal(t *testing.T) {
+	s := &Settings{Enabled: true, Split: SplitGroup}
+
+	for _, tc := range []struct {
+		status   string
+		statuses []string
+		final    bool
+	}{
+		{status: StatusFiring, statuses: []string{StatusFiring, StatusFiring}},
+		{status: StatusFiring, statuses: []string{StatusResolved, StatusFiring}},
+		{status: StatusResolved, statuses: []string{StatusResolved, StatusResolved}, final: true},
+	} {
+		events, err := s.Events(notification(t, tc.status, tc.statuses...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Final != tc.final {
+			t.Fatalf("%s %v: events = %+v, want final = %v", tc.status, tc.statuses, events, tc.final)
+		}
+	}
+}
+
+func TestEventsAlertFinal(t *testing.T) {
+	s := &Settings{Enabled: true, Split: SplitAlert}
+
+	events, err := s.Events(notification(t, StatusFiring, StatusResolved, StatusFiring))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || !events[0].Final || events[1].Final {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].Key == events[1].Key {
+		t.Fatal("alerts share a correlation key")
+	}
+}
@@ -0,0 +1,97 @@
+// Путь: internal/service/alertmanager/settings.go
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"yandex-messenger-bridge/internal/service/correlation"
+)
+
+// SettingsKey - ключ настроек режима Alertmanager в CustomSettings экземпляра
+const SettingsKey = "alertmanager"
+
+// Режимы разбиения уведомления на сообщения
+const (
+	SplitGroup = "group" // одно сообщение на группу (groupKey)
+	SplitAlert = "alert" // отдельное сообщение на каждый алерт (fingerprint)
+)
+
+// Settings - настройки обработки уведомлений Alertmanager (webhook v4)
+type Settings struct {
+	Enabled bool   `json:"enabled"`
+	Split   string `json:"split"`         // group или alert
+	Resolve string `json:"resolve"`       // как отмечать решённые: correlation.ModeThread, ModeReply или ModeReplace
+	Dedup   bool   `json:"dedup"`         // отбрасывать повторы repeat_interval без изменений
+	TTL     string `json:"ttl,omitempty"` // сколько помнить сообщение и состояние группы
+}
+
+// FromCustomSettings извлекает настройки Alertmanager из CustomSettings экземпляра.
+// Возвращает nil, если режим не включён.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid alertmanager settings: %w", err)
+	}
+	if !s.Enabled {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет настройки в CustomSettings. nil или выключенный режим удаляют настройки.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || !s.Enabled {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// Validate проверяет корректность настроек
+func (s *Settings) Validate() error {
+	switch s.Split {
+	case SplitGroup, SplitAlert:
+	default:
+		return fmt.Errorf("unknown alertmanager split mode %q", s.Split)
+	}
+
+	switch s.Resolve {
+	case correlation.ModeThread, correlation.ModeReply, correlation.ModeReplace:
+	default:
+		return fmt.Errorf("unknown alertmanager resolve mode %q", s.Resolve)
+	}
+
+	if s.TTL != "" {
+		ttl, err := time.ParseDuration(s.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid alertmanager ttl %q", s.TTL)
+		}
+	}
+	return nil
+}
+
+// Correlation возвращает настройки группировки, по которым воркер привязывает
+// resolved-уведомление к исходному сообщению. Ключ вычисляется из самого уведомления.
+func (s *Settings) Correlation() *correlation.Settings {
+	return &correlation.Settings{
+		Mode: s.Resolve,
+		TTL:  s.TTL,
+	}
+}
+
+// Duration возвращает срок хранения состояния группы
+func (s *Settings) Duration() time.Duration {
+	return s.Correlation().Duration()
+}
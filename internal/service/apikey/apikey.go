@@ -0,0 +1,41 @@
+// Путь: internal/service/apikey/apikey.go
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefix - префикс всех выдаваемых ключей, по нему ключ отличается от JWT
+const Prefix = "ymb_"
+
+// displayPrefixLen - сколько символов ключа сохраняется для отображения в списке
+const displayPrefixLen = len(Prefix) + 8
+
+// Generate создает новый ключ. Возвращает сам ключ (показывается пользователю один раз),
+// его видимый префикс и хеш для хранения в БД.
+func Generate() (key, displayPrefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key = Prefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:displayPrefixLen], Hash(key), nil
+}
+
+// Hash возвращает хеш ключа для поиска в БД.
+// Ключ содержит 256 бит случайности, поэтому медленный хеш не нужен.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey сообщает, похожа ли строка на API-ключ
+func IsKey(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
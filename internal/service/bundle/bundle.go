@@ -0,0 +1,237 @@
+// Путь: internal/service/bundle/bundle.go
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/payload"
+)
+
+// FormatVersion - версия формата пакета шаблонов
+const FormatVersion = 1
+
+// MaxSize - ограничение размера импортируемого пакета
+const MaxSize = 10 << 20
+
+// Форматы файла пакета
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// ErrUnsupportedVersion - пакет сохранён более новой версией программы
+var ErrUnsupportedVersion = errors.New("unsupported bundle version")
+
+// Bundle - переносимый пакет шаблонов с примерами данных и тестами.
+// Не содержит ID, авторов и историю версий: на другом сервере шаблоны создаются заново.
+type Bundle struct {
+	Version    int        `json:"version" yaml:"version"`
+	ExportedAt time.Time  `json:"exported_at" yaml:"exported_at"`
+	Templates  []Template `json:"templates" yaml:"templates"`
+}
+
+// Template - шаблон в пакете; совпадение по Name считается конфликтом при импорте
+type Template struct {
+	Name         string   `json:"name" yaml:"name"`
+	Icon         string   `json:"icon,omitempty" yaml:"icon,omitempty"`
+	Description  string   `json:"description,omitempty" yaml:"description,omitempty"`
+	IsPublic     bool     `json:"is_public" yaml:"is_public"`
+	TemplateText string   `json:"template_text" yaml:"template_text"`
+	Samples      []Sample `json:"samples,omitempty" yaml:"samples,omitempty"`
+	Tests        []Test   `json:"tests,omitempty" yaml:"tests,omitempty"`
+}
+
+// Sample - пример данных шаблона. Payload - JSON-значение или строка с телом запроса как есть
+// (тело не в формате JSON или JSON-строка вместе с кавычками).
+type Sample struct {
+	Name        string      `json:"name" yaml:"name"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Headers     http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Payload     interface{} `json:"payload" yaml:"payload"`
+}
+
+// Test - тест шаблона (см. domain.TemplateTest); Payload - как у Sample
+type Test struct {
+	Name     string            `json:"name" yaml:"name"`
+	Sample   string            `json:"sample,omitempty" yaml:"sample,omitempty"`
+	Payload  interface{}       `json:"payload,omitempty" yaml:"payload,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Match    string            `json:"match,omitempty" yaml:"match,omitempty"`
+	Expected string            `json:"expected" yaml:"expected"`
+}
+
+// New собирает пакет из шаблонов; примеры данных берутся из template.Samples
+func New(templates []*domain.Template) (*Bundle, error) {
+	b := &Bundle{Version: FormatVersion, ExportedAt: time.Now().UTC(), Templates: []Template{}}
+	for _, t := range templates {
+		item := Template{
+			Name:         t.Name,
+			Icon:         t.Icon,
+			Description:  t.Description,
+			IsPublic:     t.IsPublic,
+			TemplateText: t.TemplateText,
+		}
+		for _, s := range t.Samples {
+			sample := Sample{Name: s.Name, Description: s.Description}
+			if len(s.Headers) > 0 {
+				if err := json.Unmarshal(s.Headers, &sample.Headers); err != nil {
+					return nil, fmt.Errorf("template %s: sample %s: invalid headers: %w", t.Name, s.Name, err)
+				}
+			}
+			sample.Payload = decodeBody(s.Payload)
+			item.Samples = append(item.Samples, sample)
+		}
+		for _, tt := range t.Tests {
+			test := Test{Name: tt.Name, Sample: tt.Sample, Headers: tt.Headers, Match: tt.Match, Expected: tt.Expected}
+			if err := decodeRaw(tt.Payload, &test.Payload); err != nil {
+				return nil, fmt.Errorf("template %s: test %s: %w", t.Name, tt.Name, err)
+			}
+			item.Tests = append(item.Tests, test)
+		}
+		b.Templates = append(b.Templates, item)
+	}
+	return b, nil
+}
+
+// Domain возвращает шаблон пакета в виде domain.Template с примерами в Samples (без ID)
+func (t *Template) Domain() (*domain.Template, error) {
+	template := &domain.Template{
+		Name:         t.Name,
+		Icon:         t.Icon,
+		Description:  t.Description,
+		IsPublic:     t.IsPublic,
+		TemplateText: t.TemplateText,
+		Tests:        []domain.TemplateTest{},
+	}
+	for _, s := range t.Samples {
+		sample := &domain.TemplateSample{Name: s.Name, Description: s.Description}
+		if len(s.Headers) > 0 {
+			sample.Headers, _ = json.Marshal(s.Headers)
+		}
+		body, err := encodeBody(s.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("sample %s: %w", s.Name, err)
+		}
+		sample.Payload = body
+		template.Samples = append(template.Samples, sample)
+	}
+	for _, tt := range t.Tests {
+		test := domain.TemplateTest{Name: tt.Name, Sample: tt.Sample, Headers: tt.Headers, Match: tt.Match, Expected: tt.Expected}
+		if tt.Payload != nil {
+			data, err := encodeRaw(tt.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("test %s: %w", tt.Name, err)
+			}
+			test.Payload = data
+		}
+		template.Tests = append(template.Tests, test)
+	}
+	return template, nil
+}
+
+// Marshal сериализует пакет в JSON (по умолчанию) или YAML
+func Marshal(b *Bundle, format string) ([]byte, error) {
+	if format == FormatYAML {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(b); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// Unmarshal разбирает пакет в JSON или YAML: формат определяется по первому символу
+func Unmarshal(data []byte) (*Bundle, error) {
+	var b Bundle
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, errors.New("bundle is empty")
+	}
+
+	if trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &b); err != nil {
+			return nil, fmt.Errorf("invalid JSON bundle: %w", err)
+		}
+	} else if err := yaml.Unmarshal(trimmed, &b); err != nil {
+		return nil, fmt.Errorf("invalid YAML bundle: %w", err)
+	}
+
+	if b.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Version)
+	}
+	for i, t := range b.Templates {
+		if strings.TrimSpace(t.Name) == "" || t.TemplateText == "" {
+			return nil, fmt.Errorf("template #%d: name and template_text are required", i+1)
+		}
+	}
+	return &b, nil
+}
+
+// Format возвращает формат по имени, расширению файла или Content-Type (по умолчанию JSON)
+func Format(value string) string {
+	value = strings.ToLower(value)
+	if strings.HasSuffix(value, "yaml") || strings.HasSuffix(value, "yml") {
+		return FormatYAML
+	}
+	return FormatJSON
+}
+
+// decodeRaw разбирает сохранённый JSON в значение для пакета
+func decodeRaw(raw json.RawMessage, value *interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return nil
+}
+
+// decodeBody превращает сохранённое тело примера в значение для пакета: JSON-объект, массив или число
+// как есть, остальное (текст, форма, JSON-строка) - строкой с телом запроса
+func decodeBody(stored json.RawMessage) interface{} {
+	if len(stored) == 0 {
+		return nil
+	}
+	body, _ := payload.Stored(nil, stored)
+
+	var value interface{}
+	if json.Unmarshal(body, &value) == nil {
+		if _, isString := value.(string); !isString {
+			return value
+		}
+	}
+	return string(body)
+}
+
+// encodeBody готовит тело примера из пакета к хранению: строка - тело запроса как есть
+func encodeBody(value interface{}) (json.RawMessage, error) {
+	if text, ok := value.(string); ok {
+		return payload.StoredBody([]byte(text)), nil
+	}
+	return encodeRaw(value)
+}
+
+// encodeRaw превращает значение из пакета обратно в JSON для хранения
+func encodeRaw(value interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,32 @@
+// Путь: internal/service/bundle/export.go
+package bundle
+
+import (
+	"context"
+	"fmt"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+)
+
+// Export собирает пакет из шаблонов вместе с их примерами данных
+func Export(ctx context.Context, repo _interface.IntegrationRepository, templates []*domain.Template) (*Bundle, error) {
+	ids := make([]string, 0, len(templates))
+	for _, t := range templates {
+		ids = append(ids, t.ID)
+	}
+
+	list, err := repo.ListTemplateSamples(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template samples: %w", err)
+	}
+	byTemplate := make(map[string][]*domain.TemplateSample)
+	for _, sample := range list {
+		byTemplate[sample.TemplateID] = append(byTemplate[sample.TemplateID], sample)
+	}
+	for _, t := range templates {
+		t.Samples = byTemplate[t.ID]
+	}
+
+	return New(templates)
+}
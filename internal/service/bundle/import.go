@@ -0,0 +1,203 @@
+// Путь: internal/service/bundle/import.go
+package bundle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/samples"
+	"yandex-messenger-bridge/internal/service/templatetest"
+)
+
+// Что делать, если шаблон с таким названием уже есть
+const (
+	ConflictSkip      = "skip"      // оставить существующий шаблон
+	ConflictOverwrite = "overwrite" // записать новую версию существующего шаблона
+	ConflictRename    = "rename"    // создать шаблон с названием «<название> (2)»
+)
+
+// Результаты импорта шаблона
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionRenamed = "renamed"
+	ActionSkipped = "skipped"
+	ActionFailed  = "failed"
+)
+
+// Options - параметры импорта.
+// UserID - автор создаваемых шаблонов и версий (пусто - без автора, например из командной строки).
+// Admin разрешает публичные шаблоны и перезапись чужих; иначе шаблоны импортируются личными.
+type Options struct {
+	OnConflict string
+	UserID     string
+	Admin      bool
+}
+
+// Result - итог импорта одного шаблона
+type Result struct {
+	Name       string `json:"name"`
+	ImportedAs string `json:"imported_as,omitempty"`
+	TemplateID string `json:"template_id,omitempty"`
+	Action     string `json:"action"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report - итог импорта пакета
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Count возвращает количество шаблонов с данным результатом
+func (r *Report) Count(action string) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidConflict проверяет режим разрешения конфликтов (пустой - skip)
+func ValidConflict(mode string) bool {
+	switch mode {
+	case "", ConflictSkip, ConflictOverwrite, ConflictRename:
+		return true
+	}
+	return false
+}
+
+// Import создает или обновляет шаблоны пакета. Ошибка одного шаблона не останавливает импорт остальных.
+// Шаблоны проверяются тестами так же, как при сохранении: публичный с непрошедшими тестами не импортируется.
+func Import(ctx context.Context, repo _interface.IntegrationRepository, b *Bundle, opts Options) *Report {
+	report := &Report{Results: []Result{}}
+	for i := range b.Templates {
+		result := importTemplate(ctx, repo, &b.Templates[i], opts)
+		if result.Action == ActionFailed {
+			log.Warn().Str("template", result.Name).Str("error", result.Error).Msg("Template import failed")
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func importTemplate(ctx context.Context, repo _interface.IntegrationRepository, item *Template, opts Options) Result {
+	result := Result{Name: item.Name}
+	fail := func(err error) Result {
+		result.Action = ActionFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	template, err := item.Domain()
+	if err != nil {
+		return fail(err)
+	}
+	if !opts.Admin {
+		template.IsPublic = false
+	}
+
+	existing, err := findVisible(ctx, repo, template.Name, opts)
+	if err != nil {
+		return fail(err)
+	}
+	if existing != nil && opts.OnConflict != ConflictOverwrite && opts.OnConflict != ConflictRename {
+		result.Action = ActionSkipped
+		result.TemplateID = existing.ID
+		return result
+	}
+
+	for _, sample := range template.Samples {
+		if err := samples.ValidateName(sample.Name); err != nil {
+			return fail(fmt.Errorf("sample %q: %w", sample.Name, err))
+		}
+	}
+	if err := templatetest.Validate(template.Tests); err != nil {
+		return fail(err)
+	}
+	if report, err := templatetest.Check(template); err != nil {
+		return fail(fmt.Errorf("%w: %s", err, report.Summary()))
+	}
+
+	template.ChangeAuthor = opts.UserID
+	template.ChangeNote = "Импорт из пакета"
+	if existing != nil {
+		switch opts.OnConflict {
+		case ConflictOverwrite:
+			if !opts.Admin && (!existing.CreatedBy.Valid || existing.CreatedBy.String != opts.UserID) {
+				return fail(fmt.Errorf("template %q belongs to another user", existing.Name))
+			}
+			template.ID = existing.ID
+			template.CreatedBy = existing.CreatedBy
+			if err := repo.UpdateTemplate(ctx, template); err != nil {
+				return fail(fmt.Errorf("failed to update template: %w", err))
+			}
+			result.Action = ActionUpdated
+		case ConflictRename:
+			name, err := freeName(ctx, repo, template.Name, opts)
+			if err != nil {
+				return fail(err)
+			}
+			template.Name = name
+			result.Action = ActionRenamed
+		}
+	}
+
+	if template.ID == "" {
+		template.CreatedBy = sql.NullString{String: opts.UserID, Valid: opts.UserID != ""}
+		if err := repo.CreateTemplate(ctx, template); err != nil {
+			return fail(fmt.Errorf("failed to create template: %w", err))
+		}
+		if result.Action == "" {
+			result.Action = ActionCreated
+		}
+	}
+
+	// Примеры с теми же именами заменяются, остальные примеры существующего шаблона остаются
+	for _, sample := range template.Samples {
+		sample.TemplateID = template.ID
+		if err := repo.SaveTemplateSample(ctx, sample); err != nil {
+			return fail(fmt.Errorf("failed to save sample %s: %w", sample.Name, err))
+		}
+	}
+
+	result.ImportedAs = template.Name
+	result.TemplateID = template.ID
+	return result
+}
+
+// findVisible ищет шаблон с таким названием среди доступных импортирующему:
+// администратору - среди всех, пользователю - среди своих и публичных
+func findVisible(ctx context.Context, repo _interface.IntegrationRepository, name string, opts Options) (*domain.Template, error) {
+	templates, err := repo.FindTemplatesByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up template %q: %w", name, err)
+	}
+	for _, t := range templates {
+		if opts.Admin || t.IsPublic || (t.CreatedBy.Valid && t.CreatedBy.String == opts.UserID) {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// freeName подбирает свободное название: «<название> (2)», «<название> (3)», ...
+func freeName(ctx context.Context, repo _interface.IntegrationRepository, name string, opts Options) (string, error) {
+	for n := 2; n < 1000; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		existing, err := findVisible(ctx, repo, candidate, opts)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name for template %q", name)
+}
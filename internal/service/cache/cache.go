@@ -0,0 +1,254 @@
+// Путь: internal/service/cache/cache.go
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osteele/liquid"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/render"
+	"yandex-messenger-bridge/internal/service/routing"
+)
+
+// Cache - кэш экземпляров с шаблонами и разобранных Liquid-шаблонов для обработки вебхуков.
+// Записи сбрасываются по уведомлениям об изменении экземпляра или шаблона (см. Listener),
+// а также по истечении ttl - на случай пропущенного уведомления. Истёкшие записи, к которым больше
+// не обращаются (старые версии шаблонов, удалённые экземпляры), удаляются при очередной вставке не реже раза в ttl.
+type Cache struct {
+	repo _interface.IntegrationRepository
+	ttl  time.Duration
+
+	mu        sync.RWMutex
+	instances map[string]*instanceEntry
+	templates map[string]*templateEntry
+	// generation растёт при каждом сбросе: экземпляр, загруженный до сброса, не попадает в кэш
+	generation uint64
+	sweptAt    time.Time // время последнего удаления истёкших записей
+}
+
+// instanceEntry - экземпляр вместе с шаблоном и правилами, скомпилированными при первом обращении
+type instanceEntry struct {
+	instance *domain.IntegrationInstance
+	loadedAt time.Time
+
+	rulesOnce sync.Once
+	rules     *Rules
+}
+
+// Rules - скомпилированные правила фильтрации и маршруты экземпляра.
+// Ошибки компиляции хранятся вместе с результатом: каждый вебхук получает ту же ошибку, что и при разборе.
+type Rules struct {
+	Filter    *filter.Filter // nil - правил фильтрации нет
+	FilterErr error
+	Router    *routing.Router // nil - маршрутов нет
+	RouterErr error
+}
+
+// templateEntry - разобранный шаблон и текст, из которого он получен
+type templateEntry struct {
+	text     string
+	template *liquid.Template
+	loadedAt time.Time
+}
+
+// NewCache создает кэш. При ttl <= 0 кэш выключен: экземпляры загружаются из БД, шаблоны разбираются на каждый запрос.
+func NewCache(repo _interface.IntegrationRepository, ttl time.Duration) *Cache {
+	return &Cache{
+		repo:      repo,
+		ttl:       ttl,
+		instances: make(map[string]*instanceEntry),
+		templates: make(map[string]*templateEntry),
+		sweptAt:   time.Now(),
+	}
+}
+
+// Enabled сообщает, включён ли кэш
+func (c *Cache) Enabled() bool {
+	return c.ttl > 0
+}
+
+// Instance возвращает экземпляр с шаблоном (как GetInstanceWithTemplate для вебхуков).
+// Возвращается копия структуры: поля верхнего уровня можно менять, вложенные CustomSettings и Template - нет.
+func (c *Cache) Instance(ctx context.Context, id string) (*domain.IntegrationInstance, error) {
+	if !c.Enabled() {
+		return c.repo.GetInstanceWithTemplate(ctx, id, "")
+	}
+
+	c.mu.RLock()
+	entry, ok := c.instances[id]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < c.ttl {
+		instance := *entry.instance
+		return &instance, nil
+	}
+
+	instance, err := c.repo.GetInstanceWithTemplate(ctx, id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.sweep()
+		c.instances[id] = &instanceEntry{instance: instance, loadedAt: time.Now()}
+	}
+	c.mu.Unlock()
+
+	copied := *instance
+	return &copied, nil
+}
+
+// Rules возвращает скомпилированные правила фильтрации и маршруты экземпляра. Для экземпляра из кэша
+// регулярные выражения и Liquid-условия разбираются один раз на запись, а не на каждый вебхук.
+func (c *Cache) Rules(instance *domain.IntegrationInstance) *Rules {
+	if !c.Enabled() {
+		return compileRules(instance)
+	}
+
+	c.mu.RLock()
+	entry, ok := c.instances[instance.ID]
+	c.mu.RUnlock()
+	// Экземпляр с другим updated_at (загруженный из БД после изменения) компилируется отдельно
+	if !ok || !entry.instance.UpdatedAt.Equal(instance.UpdatedAt) {
+		return compileRules(instance)
+	}
+
+	entry.rulesOnce.Do(func() {
+		entry.rules = compileRules(entry.instance)
+	})
+	return entry.rules
+}
+
+// compileRules компилирует правила фильтрации и маршруты из CustomSettings экземпляра
+func compileRules(instance *domain.IntegrationInstance) *Rules {
+	engine := render.Engine()
+	rules := &Rules{}
+
+	filters, err := filter.FromCustomSettings(instance.CustomSettings)
+	if err != nil {
+		rules.FilterErr = err
+	} else if filters != nil {
+		rules.Filter, rules.FilterErr = filters.Compile(engine)
+	}
+
+	routes, err := routing.FromCustomSettings(instance.CustomSettings)
+	if err != nil {
+		rules.RouterErr = err
+	} else if routes != nil {
+		rules.Router, rules.RouterErr = routes.Compile(engine)
+	}
+	return rules
+}
+
+// TemplateKey - ключ разобранного шаблона экземпляра: ID шаблона, версия (своя у закреплённых экземпляров)
+// и время её создания
+func TemplateKey(template *domain.Template) string {
+	return "template:" + template.ID + "@v" + strconv.Itoa(template.Version) + "@" + strconv.FormatInt(template.UpdatedAt.UnixNano(), 10)
+}
+
+// RouteTemplateKey - ключ разобранного шаблона маршрута: ID экземпляра, время его изменения и хэш текста
+func RouteTemplateKey(instance *domain.IntegrationInstance, text string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	return "instance:" + instance.ID + "@" + strconv.FormatInt(instance.UpdatedAt.UnixNano(), 10) + "#" + strconv.FormatUint(hash.Sum64(), 16)
+}
+
+// Template возвращает разобранный шаблон по ключу, разбирая текст при первом обращении.
+// Если под ключом закэширован другой текст, шаблон разбирается заново.
+func (c *Cache) Template(key, text string) (*liquid.Template, error) {
+	if !c.Enabled() {
+		return render.Parse(text)
+	}
+
+	c.mu.RLock()
+	entry, ok := c.templates[key]
+	c.mu.RUnlock()
+	if ok && entry.text == text && time.Since(entry.loadedAt) < c.ttl {
+		return entry.template, nil
+	}
+
+	template, err := render.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.sweep()
+	c.templates[key] = &templateEntry{text: text, template: template, loadedAt: time.Now()}
+	c.mu.Unlock()
+
+	return template, nil
+}
+
+// InvalidateInstance сбрасывает экземпляр и шаблоны его маршрутов
+func (c *Cache) InvalidateInstance(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	delete(c.instances, id)
+	c.deleteTemplates("instance:" + id + "@")
+}
+
+// InvalidateTemplate сбрасывает шаблон и все экземпляры, которые его используют
+func (c *Cache) InvalidateTemplate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for instanceID, entry := range c.instances {
+		if entry.instance.TemplateID == id {
+			delete(c.instances, instanceID)
+		}
+	}
+	c.deleteTemplates("template:" + id + "@")
+}
+
+// Flush сбрасывает весь кэш
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.instances = make(map[string]*instanceEntry)
+	c.templates = make(map[string]*templateEntry)
+	c.sweptAt = time.Now()
+}
+
+// sweep удаляет истёкшие записи, если с прошлого удаления прошло больше ttl (вызывается под блокировкой)
+func (c *Cache) sweep() {
+	now := time.Now()
+	if now.Sub(c.sweptAt) < c.ttl {
+		return
+	}
+	c.sweptAt = now
+
+	for id, entry := range c.instances {
+		if now.Sub(entry.loadedAt) >= c.ttl {
+			delete(c.instances, id)
+		}
+	}
+	for key, entry := range c.templates {
+		if now.Sub(entry.loadedAt) >= c.ttl {
+			delete(c.templates, key)
+		}
+	}
+}
+
+// deleteTemplates удаляет разобранные шаблоны с ключами, начинающимися с prefix (вызывается под блокировкой)
+func (c *Cache) deleteTemplates(prefix string) {
+	for key := range c.templates {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.templates, key)
+		}
+	}
+}
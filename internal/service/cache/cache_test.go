@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/routing"
+)
+
+// benchRepo отдаёт копию одного экземпляра, как GetInstanceWithTemplate после запроса к БД
+type benchRepo struct {
+	_interface.IntegrationRepository
+	instance *domain.IntegrationInstance
+}
+
+func (r *benchRepo) GetInstanceWithTemplate(ctx context.Context, id string, userID string) (*domain.IntegrationInstance, error) {
+	instance := *r.instance
+	template := *r.instance.Template
+	instance.Template = &template
+	return &instance, nil
+}
+
+const benchTemplate = `{%- if object_kind == "pipeline" -%}
+{%- assign pipeline = object_attributes -%}
+{%- case pipeline.status -%}
+  {%- when "success" -%}{%- assign heading = "✅ Пайплайн прошёл" -%}
+  {%- when "failed" -%}{%- assign heading = "❌ Пайплайн упал" -%}
+{%- endcase -%}
+---
+buttons:
+  - text: Открыть пайплайн
+    url: {{ pipeline.url }}
+---
+{{ heading }} #{{ pipeline.id }} · ` + "`{{ pipeline.ref }}`" + ` · {{ project.path_with_namespace }}
+📝 {{ commit.title | escape_markdown }} — {{ commit.author.name }}
+⏱️ {{ pipeline.duration | duration }}
+{%- for build in builds %}
+{%- if build.status == "failed" %}
+• {{ build.stage }} / **{{ build.name }}**
+{%- endif %}
+{%- endfor %}
+{%- endif -%}`
+
+const benchPayload = `{
+  "object_kind": "pipeline",
+  "object_attributes": {"id": 3141, "ref": "main", "status": "failed", "duration": 431,
+    "url": "https://gitlab.example.com/acme/billing/-/pipelines/3141"},
+  "project": {"path_with_namespace": "acme/billing"},
+  "commit": {"title": "Add VAT_RATE setting", "author": {"name": "Мария Смирнова"}},
+  "builds": [
+    {"stage": "test", "name": "unit", "status": "failed"},
+    {"stage": "test", "name": "lint", "status": "success"},
+    {"stage": "build", "name": "build", "status": "success"}
+  ]
+}`
+
+// BenchmarkWebhookRender сравнивает путь вебхука от загрузки экземпляра до текста сообщения
+// с кэшем и без него: без кэша на каждый запрос разбираются шаблон, правила фильтрации и маршруты.
+// Запрос к БД не моделируется, поэтому реальный выигрыш больше.
+func BenchmarkWebhookRender(b *testing.B) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(benchPayload), &data); err != nil {
+		b.Fatal(err)
+	}
+	in := filter.Input{Header: http.Header{"X-Gitlab-Event": {"Pipeline Hook"}}, Body: []byte(benchPayload), Data: data}
+
+	instance := &domain.IntegrationInstance{
+		ID:     "instance-1",
+		ChatID: "chat-1",
+		CustomSettings: map[string]interface{}{
+			filter.SettingsKey: map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"match": "header", "header": "X-Gitlab-Event", "pattern": "^(Push|Tag Push) Hook$", "action": "drop"},
+					map[string]interface{}{"match": "liquid", "expr": `object_attributes.status == "running"`, "action": "drop"},
+				},
+			},
+			routing.SettingsKey: map[string]interface{}{
+				"routes": []interface{}{
+					map[string]interface{}{"match": "json_path", "path": "object_attributes.ref", "pattern": "^release/", "chat_id": "chat-release"},
+				},
+			},
+		},
+		UpdatedAt: time.Now(),
+		Template: &domain.Template{
+			ID:           "template-1",
+			Version:      1,
+			TemplateText: benchTemplate,
+			UpdatedAt:    time.Now(),
+		},
+	}
+
+	for _, bc := range []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{name: "cached", ttl: time.Minute},
+		{name: "uncached", ttl: 0},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			c := NewCache(&benchRepo{instance: instance}, bc.ttl)
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				inst, err := c.Instance(ctx, instance.ID)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				rules := c.Rules(inst)
+				if rules.FilterErr != nil || rules.RouterErr != nil {
+					b.Fatal(rules.FilterErr, rules.RouterErr)
+				}
+				if action, _, err := rules.Filter.Evaluate(in); err != nil || action != filter.ActionSend {
+					b.Fatal(action, err)
+				}
+				if _, err := rules.Router.Resolve(in, inst.ChatID); err != nil {
+					b.Fatal(err)
+				}
+
+				tpl, err := c.Template(TemplateKey(inst.Template), inst.Template.TemplateText)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := tpl.RenderString(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Путь: internal/service/cache/listener.go
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// Listener слушает канал domain.CacheChannel и сбрасывает записи кэша на всех репликах
+type Listener struct {
+	cache    *Cache
+	listener *pq.Listener
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewListener создает слушателя уведомлений для кэша
+func NewListener(cache *Cache, dsn string) *Listener {
+	l := &Listener{
+		cache: cache,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	l.listener = pq.NewListener(dsn, time.Second, time.Minute, l.event)
+	return l
+}
+
+// Start подписывается на канал и обрабатывает уведомления в фоне.
+// При ошибке подписки соединение закрывается, Stop вызывать не нужно.
+func (l *Listener) Start() error {
+	if err := l.listener.Listen(domain.CacheChannel); err != nil {
+		l.listener.Close()
+		return err
+	}
+
+	go func() {
+		defer close(l.done)
+
+		for {
+			select {
+			case <-l.stop:
+				return
+			case n := <-l.listener.Notify:
+				l.handle(n)
+			case <-time.After(90 * time.Second):
+				// Проверяем соединение: обрыв без уведомления об ошибке приведёт к переподключению
+				go l.listener.Ping()
+			}
+		}
+	}()
+
+	log.Info().Str("channel", domain.CacheChannel).Msg("🗂️ Template cache listener started")
+	return nil
+}
+
+// Stop отписывается от канала и закрывает соединение
+func (l *Listener) Stop() {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	l.listener.Close()
+}
+
+// handle применяет уведомление к кэшу
+func (l *Listener) handle(n *pq.Notification) {
+	// nil приходит после переподключения: уведомления за время обрыва потеряны
+	if n == nil {
+		l.cache.Flush()
+		return
+	}
+
+	kind, id, ok := strings.Cut(n.Extra, ":")
+	if !ok {
+		log.Warn().Str("payload", n.Extra).Msg("Unknown cache notification")
+		return
+	}
+
+	switch kind {
+	case domain.CacheKindInstance:
+		l.cache.InvalidateInstance(id)
+	case domain.CacheKindTemplate:
+		l.cache.InvalidateTemplate(id)
+	default:
+		log.Warn().Str("payload", n.Extra).Msg("Unknown cache notification")
+	}
+}
+
+// event логирует состояние соединения слушателя
+func (l *Listener) event(ev pq.ListenerEventType, err error) {
+	switch ev {
+	case pq.ListenerEventDisconnected:
+		log.Warn().Err(err).Msg("Template cache listener disconnected")
+	case pq.ListenerEventReconnected:
+		log.Info().Msg("Template cache listener reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		log.Error().Err(err).Msg("Template cache listener failed to connect")
+	}
+}
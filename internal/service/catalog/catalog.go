@@ -0,0 +1,79 @@
+// Путь: internal/service/catalog/catalog.go
+
+// Package catalog - встроенный каталог шаблонов. Шаблоны лежат в templates/*.yaml, встраиваются в бинарник
+// и при запуске устанавливаются или обновляются в таблице templates (см. Sync).
+package catalog
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"yandex-messenger-bridge/internal/service/bundle"
+	"yandex-messenger-bridge/internal/service/samples"
+	"yandex-messenger-bridge/internal/service/templatetest"
+)
+
+//go:embed templates/*.yaml
+var files embed.FS
+
+// Entry - шаблон каталога: шаблон в формате пакета (bundle.Template) с ключом и ревизией.
+// Ключ - имя файла без расширения. Ревизию нужно увеличивать при каждом изменении файла:
+// по ней сервер понимает, что установленный шаблон устарел.
+type Entry struct {
+	Key             string `yaml:"-"`
+	Revision        int    `yaml:"revision"`
+	bundle.Template `yaml:",inline"`
+}
+
+// Load разбирает все шаблоны каталога в порядке ключей. Шаблоны каталога всегда публичные.
+func Load() ([]*Entry, error) {
+	paths, err := fs.Glob(files, "templates/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(paths))
+	for _, name := range paths {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &Entry{Key: strings.TrimSuffix(path.Base(name), ".yaml")}
+		if err := yaml.Unmarshal(data, entry); err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", entry.Key, err)
+		}
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", entry.Key, err)
+		}
+		entry.IsPublic = true
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// validate проверяет обязательные поля, имена примеров и описание тестов
+func (e *Entry) validate() error {
+	if e.Revision < 1 {
+		return fmt.Errorf("revision must be positive")
+	}
+	if strings.TrimSpace(e.Name) == "" || e.TemplateText == "" {
+		return fmt.Errorf("name and template_text are required")
+	}
+	for _, sample := range e.Samples {
+		if err := samples.ValidateName(sample.Name); err != nil {
+			return fmt.Errorf("sample %q: %w", sample.Name, err)
+		}
+	}
+
+	template, err := e.Domain()
+	if err != nil {
+		return err
+	}
+	return templatetest.Validate(template.Tests)
+}
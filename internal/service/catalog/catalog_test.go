@@ -0,0 +1,44 @@
+package catalog
+
+import (
+	"testing"
+
+	"yandex-messenger-bridge/internal/service/templatetest"
+)
+
+// TestCatalogTemplates проверяет, что каждый шаблон каталога разбирается и проходит свои тесты:
+// иначе сломанная ревизия обнаружилась бы только при запуске как ActionFailed
+func TestCatalogTemplates(t *testing.T) {
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("catalog is empty")
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		t.Run(entry.Key, func(t *testing.T) {
+			if names[entry.Name] {
+				t.Fatalf("duplicate template name %q", entry.Name)
+			}
+			names[entry.Name] = true
+
+			template, err := entry.Domain()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(template.Tests) == 0 {
+				t.Fatal("catalog template has no tests")
+			}
+
+			report := templatetest.Run(template)
+			for _, result := range report.Results {
+				if !result.Passed {
+					t.Errorf("%s: %s\noutput:\n%s", result.Name, result.Error, result.Output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Путь: internal/service/catalog/sync.go
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/templatetest"
+)
+
+// Результаты синхронизации шаблона каталога
+const (
+	ActionInstalled = "installed"  // шаблон установлен впервые
+	ActionUpdated   = "updated"    // записана новая версия шаблона
+	ActionUpToDate  = "up_to_date" // установлена текущая или более новая ревизия
+	ActionModified  = "modified"   // шаблон правили вручную, обновление не применено
+	ActionDeleted   = "deleted"    // шаблон удалён администратором и заново не устанавливается
+	ActionConflict  = "conflict"   // есть другой публичный шаблон с таким названием
+	ActionFailed    = "failed"
+)
+
+// Result - итог синхронизации одного шаблона каталога
+type Result struct {
+	Key        string `json:"key"`
+	Name       string `json:"name"`
+	Revision   int    `json:"revision"`
+	TemplateID string `json:"template_id,omitempty"`
+	Action     string `json:"action"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Sync устанавливает новые шаблоны каталога и обновляет установленные до ревизии из бинарника.
+// Шаблон, который после последней синхронизации правили вручную, не перезаписывается.
+// Ошибка одного шаблона не останавливает синхронизацию остальных.
+func Sync(ctx context.Context, repo _interface.IntegrationRepository) ([]Result, error) {
+	entries, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := repo.LockCatalogSync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock template catalog: %w", err)
+	}
+	defer unlock()
+
+	results := make([]Result, 0, len(entries))
+	for _, entry := range entries {
+		result := syncEntry(ctx, repo, entry)
+		logResult(result)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func syncEntry(ctx context.Context, repo _interface.IntegrationRepository, entry *Entry) Result {
+	result := Result{Key: entry.Key, Name: entry.Name, Revision: entry.Revision}
+	fail := func(err error) Result {
+		result.Action = ActionFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	installed, err := repo.GetCatalogTemplate(ctx, entry.Key)
+	if errors.Is(err, sql.ErrNoRows) {
+		installed = nil
+	} else if err != nil {
+		return fail(fmt.Errorf("failed to load catalog state: %w", err))
+	}
+
+	if installed != nil {
+		if installed.TemplateID == nil {
+			result.Action = ActionDeleted
+			return result
+		}
+		result.TemplateID = *installed.TemplateID
+		if installed.Revision >= entry.Revision {
+			result.Action = ActionUpToDate
+			return result
+		}
+	}
+
+	template, err := entry.Domain()
+	if err != nil {
+		return fail(err)
+	}
+	if report, err := templatetest.Check(template); err != nil {
+		return fail(fmt.Errorf("%w: %s", err, report.Summary()))
+	}
+
+	if installed == nil {
+		result.Action, err = install(ctx, repo, entry, template)
+	} else {
+		result.Action, err = update(ctx, repo, entry, template, installed)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if template.ID != "" {
+		result.TemplateID = template.ID
+	}
+	return result
+}
+
+// install создает шаблон каталога без автора. Публичный шаблон с тем же названием
+// (например, вставленный вручную из README) не трогается: каталог его не заменяет.
+func install(ctx context.Context, repo _interface.IntegrationRepository, entry *Entry, template *domain.Template) (string, error) {
+	existing, err := repo.FindTemplatesByName(ctx, template.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up template %q: %w", template.Name, err)
+	}
+	for _, t := range existing {
+		if t.IsPublic {
+			return ActionConflict, nil
+		}
+	}
+
+	template.ChangeNote = fmt.Sprintf("Каталог: %s r%d", entry.Key, entry.Revision)
+	if err := repo.CreateTemplate(ctx, template); err != nil {
+		return "", fmt.Errorf("failed to create template: %w", err)
+	}
+	if err := save(ctx, repo, entry, template); err != nil {
+		return "", err
+	}
+	return ActionInstalled, nil
+}
+
+// update записывает новую версию установленного шаблона, если его не правили вручную.
+// Видимость, выбранная администратором, сохраняется.
+func update(ctx context.Context, repo _interface.IntegrationRepository, entry *Entry, template *domain.Template, installed *domain.CatalogTemplate) (string, error) {
+	current, err := repo.GetTemplateByID(ctx, *installed.TemplateID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load template: %w", err)
+	}
+
+	changed, err := modified(ctx, repo, current, installed)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		return ActionModified, nil
+	}
+
+	template.ID = current.ID
+	template.CreatedBy = current.CreatedBy
+	template.IsPublic = current.IsPublic
+	template.ChangeNote = fmt.Sprintf("Каталог: %s r%d", entry.Key, entry.Revision)
+	if err := repo.UpdateTemplate(ctx, template); err != nil {
+		return "", fmt.Errorf("failed to update template: %w", err)
+	}
+	if err := save(ctx, repo, entry, template); err != nil {
+		return "", err
+	}
+	return ActionUpdated, nil
+}
+
+// modified сообщает, что шаблон правили после последней синхронизации.
+// Откат к версии, записанной каталогом, правкой не считается: обновления снова применяются.
+func modified(ctx context.Context, repo _interface.IntegrationRepository, current *domain.Template, installed *domain.CatalogTemplate) (bool, error) {
+	if current.Version == installed.TemplateVersion {
+		return false, nil
+	}
+
+	synced, err := repo.GetTemplateVersion(ctx, current.ID, installed.TemplateVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load template version: %w", err)
+	}
+
+	sameTests := len(synced.Tests) == 0 && len(current.Tests) == 0 || reflect.DeepEqual(synced.Tests, current.Tests)
+	return synced.Name != current.Name || synced.Description != current.Description || synced.Icon != current.Icon ||
+		synced.TemplateText != current.TemplateText || !sameTests, nil
+}
+
+// save сохраняет примеры данных шаблона и записывает синхронизированную ревизию.
+// Примеры с теми же именами заменяются, добавленные пользователями остаются.
+func save(ctx context.Context, repo _interface.IntegrationRepository, entry *Entry, template *domain.Template) error {
+	for _, sample := range template.Samples {
+		sample.TemplateID = template.ID
+		if err := repo.SaveTemplateSample(ctx, sample); err != nil {
+			return fmt.Errorf("failed to save sample %s: %w", sample.Name, err)
+		}
+	}
+
+	return repo.SaveCatalogTemplate(ctx, &domain.CatalogTemplate{
+		Key:             entry.Key,
+		TemplateID:      &template.ID,
+		Revision:        entry.Revision,
+		TemplateVersion: template.Version,
+	})
+}
+
+func logResult(result Result) {
+	event := log.Info()
+	switch result.Action {
+	case ActionUpToDate, ActionDeleted:
+		event = log.Debug()
+	case ActionModified, ActionConflict:
+		event = log.Warn()
+	case ActionFailed:
+		event = log.Error().Str("error", result.Error)
+	}
+	event.Str("key", result.Key).Str("template", result.Name).Int("revision", result.Revision).
+		Str("action", result.Action).Msg("Template catalog sync")
+}
@@ -0,0 +1,103 @@
+// Путь: internal/service/correlation/settings.go
+package correlation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/osteele/liquid"
+
+	"yandex-messenger-bridge/internal/service/render"
+)
+
+// SettingsKey - ключ настроек группировки в CustomSettings экземпляра
+const SettingsKey = "correlation"
+
+// Режимы обработки повторных событий по одному объекту
+const (
+	ModeReply   = "reply"   // ответ на первое сообщение (reply_message_id)
+	ModeThread  = "thread"  // сообщение в треде первого сообщения (thread_id)
+	ModeReplace = "replace" // предыдущее сообщение удаляется и отправляется новое
+)
+
+// DefaultTTL - сколько помнить сообщение по ключу, если срок не задан
+const DefaultTTL = 7 * 24 * time.Hour
+
+// Settings - настройки группировки сообщений по ключу корреляции
+type Settings struct {
+	Key  string `json:"key"`           // Liquid-выражение, например {{ issue.key }}
+	Mode string `json:"mode"`          // reply, thread или replace
+	TTL  string `json:"ttl,omitempty"` // срок хранения связи ключ → сообщение, например 168h
+}
+
+// FromCustomSettings извлекает настройки группировки из CustomSettings экземпляра.
+// Возвращает nil, если группировка не настроена.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid correlation settings: %w", err)
+	}
+	if strings.TrimSpace(s.Key) == "" {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет настройки в CustomSettings. nil или пустое выражение отключают группировку.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || strings.TrimSpace(s.Key) == "" {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// Validate проверяет корректность настроек
+func (s *Settings) Validate() error {
+	switch s.Mode {
+	case ModeReply, ModeThread, ModeReplace:
+	default:
+		return fmt.Errorf("unknown correlation mode %q", s.Mode)
+	}
+
+	if _, err := render.Engine().ParseString(s.Key); err != nil {
+		return fmt.Errorf("invalid correlation key expression: %w", err)
+	}
+
+	if s.TTL != "" {
+		ttl, err := time.ParseDuration(s.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid correlation ttl %q", s.TTL)
+		}
+	}
+	return nil
+}
+
+// Duration возвращает срок хранения связи ключ → сообщение
+func (s *Settings) Duration() time.Duration {
+	if ttl, err := time.ParseDuration(s.TTL); err == nil && ttl > 0 {
+		return ttl
+	}
+	return DefaultTTL
+}
+
+// RenderKey вычисляет ключ корреляции для данных вебхука. Пустой результат означает «без группировки».
+func (s *Settings) RenderKey(engine *liquid.Engine, data map[string]interface{}) (string, error) {
+	key, err := engine.ParseAndRenderString(s.Key, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render correlation key: %w", err)
+	}
+	return strings.TrimSpace(key), nil
+}
@@ -0,0 +1,118 @@
+// Путь: internal/service/dedup/settings.go
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/osteele/liquid"
+
+	"yandex-messenger-bridge/internal/service/render"
+)
+
+// SettingsKey - ключ настроек дедупликации в CustomSettings экземпляра
+const SettingsKey = "dedup"
+
+// DefaultWindow - в течение какого времени повтор считается дублем, если окно не задано
+const DefaultWindow = time.Hour
+
+// SuggestedHeaders - заголовки, в которых источники обычно передают идентификатор доставки
+var SuggestedHeaders = []string{
+	"X-Gitlab-Event-UUID",
+	"X-GitHub-Delivery",
+	"X-Request-Id",
+	"Idempotency-Key",
+}
+
+// Settings - настройки отбрасывания повторно доставленных вебхуков
+type Settings struct {
+	Enabled bool   `json:"enabled"`
+	Header  string `json:"header,omitempty"` // заголовок с ключом идемпотентности
+	Key     string `json:"key,omitempty"`    // Liquid-выражение над телом, например {{ webhookEvent }}-{{ issue.id }}-{{ timestamp }}
+	Window  string `json:"window,omitempty"` // окно дедупликации, например 1h
+}
+
+// FromCustomSettings извлекает настройки дедупликации из CustomSettings экземпляра.
+// Возвращает nil, если дедупликация не включена.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid dedup settings: %w", err)
+	}
+	if !s.Enabled {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет настройки в CustomSettings. nil или выключенная дедупликация удаляют настройки.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || !s.Enabled {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// Validate проверяет корректность настроек
+func (s *Settings) Validate() error {
+	if s.Key != "" {
+		if _, err := render.Engine().ParseString(s.Key); err != nil {
+			return fmt.Errorf("invalid dedup key expression: %w", err)
+		}
+	}
+
+	if s.Window != "" {
+		window, err := time.ParseDuration(s.Window)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("invalid dedup window %q", s.Window)
+		}
+	}
+	return nil
+}
+
+// Duration возвращает окно дедупликации
+func (s *Settings) Duration() time.Duration {
+	if window, err := time.ParseDuration(s.Window); err == nil && window > 0 {
+		return window
+	}
+	return DefaultWindow
+}
+
+// KeyFor вычисляет ключ дедупликации запроса: значение заголовка, затем Liquid-выражение,
+// а если ни то ни другое не дало значения - SHA-256 тела запроса.
+func (s *Settings) KeyFor(header http.Header, body []byte, engine *liquid.Engine, data map[string]interface{}) (string, error) {
+	if s.Header != "" {
+		if value := strings.TrimSpace(header.Get(s.Header)); value != "" {
+			return "header:" + value, nil
+		}
+	}
+
+	if s.Key != "" {
+		value, err := engine.ParseAndRenderString(s.Key, data)
+		if err != nil {
+			return "", fmt.Errorf("failed to render dedup key: %w", err)
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			return "key:" + value, nil
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	return "body:" + hex.EncodeToString(sum[:]), nil
+}
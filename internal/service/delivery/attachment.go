@@ -0,0 +1,219 @@
+// Путь: internal/service/delivery/attachment.go
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxAttachmentSize - максимальный размер скачиваемого вложения
+const maxAttachmentSize = 20 * 1024 * 1024
+
+// errForbiddenAddress - ссылка на вложение ведёт во внутреннюю сеть
+var errForbiddenAddress = errors.New("attachment address is not public")
+
+// blockedNetworks - служебные сети, которых нет среди проверок net.IP
+var blockedNetworks = mustParseNetworks(
+	"0.0.0.0/8",     // "эта" сеть
+	"100.64.0.0/10", // CGNAT (RFC 6598)
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // сети для тестирования производительности
+	"240.0.0.0/4",   // зарезервировано
+)
+
+// attachmentAllowlist - внутренние хосты и подсети, с которых разрешено скачивать вложения
+// (например, Grafana, отдающая скриншоты панелей из внутренней сети)
+type attachmentAllowlist struct {
+	hosts    []string     // имя хоста; с точкой в начале - домен вместе с поддоменами
+	networks []*net.IPNet // подсети и отдельные адреса
+}
+
+// parseAttachmentAllowlist разбирает записи списка: IP-адрес, подсеть CIDR, имя хоста
+// или домен с точкой в начале (.corp.example.com). Ошибочные записи пропускаются с предупреждением.
+func parseAttachmentAllowlist(entries []string) *attachmentAllowlist {
+	allowlist := &attachmentAllowlist{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				log.Warn().Str("entry", entry).Msg("Invalid CIDR in attachment allowlist")
+				continue
+			}
+			allowlist.networks = append(allowlist.networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			allowlist.networks = append(allowlist.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			allowlist.hosts = append(allowlist.hosts, strings.TrimPrefix(entry, "*"))
+		}
+	}
+	return allowlist
+}
+
+// allowsHost сообщает, что хост из ссылки внесён в список
+func (a *attachmentAllowlist) allowsHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range a.hosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsIP сообщает, что с адреса можно скачивать: он публичный или входит в подсеть из списка
+func (a *attachmentAllowlist) allowsIP(ip net.IP) bool {
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return isPublicIP(ip)
+}
+
+// newAttachmentClient создает HTTP-клиент для скачивания вложений.
+// Ссылки берутся из тела вебхука, поэтому соединения с loopback, частными, link-local и служебными адресами
+// запрещены на уровне dialer: проверяется уже разрешённый IP, в том числе после редиректов.
+// Хосты и подсети из allowlist разрешены, несмотря на внутренний адрес.
+func newAttachmentClient(timeout time.Duration, allowlist []string) *http.Client {
+	allowed := parseAttachmentAllowlist(allowlist)
+
+	trusted := &net.Dialer{Timeout: timeout}
+	restricted := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !allowed.allowsIP(ip) {
+				return fmt.Errorf("%w: %s", errForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // прокси обошёл бы проверку адреса
+	// Адрес здесь ещё с именем хоста: хост из списка подключается без проверки IP
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(address); err == nil && allowed.allowsHost(host) {
+			return trusted.DialContext(ctx, network, address)
+		}
+		return restricted.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return checkAttachmentURL(req.URL)
+		},
+	}
+}
+
+// isPublicIP сообщает, что адрес не относится к loopback, частным, link-local и служебным сетям
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// mustParseNetworks разбирает подсети CIDR, заданные в коде
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// checkAttachmentURL разрешает только http и https ссылки
+func checkAttachmentURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported attachment URL scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+// fetchAttachment скачивает вложение по ссылке из front matter шаблона
+func fetchAttachment(ctx context.Context, client *http.Client, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create attachment request: %w", err)
+	}
+	if err := checkAttachmentURL(req.URL); err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download attachment: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if len(content) > maxAttachmentSize {
+		return nil, "", fmt.Errorf("attachment is larger than %d bytes", maxAttachmentSize)
+	}
+
+	return content, attachmentName(url), nil
+}
+
+// attachmentName берёт имя файла из последнего сегмента пути ссылки
+func attachmentName(url string) string {
+	name := url
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	name = path.Base(name)
+	if name == "." || name == "/" || !strings.Contains(name, ".") {
+		return ""
+	}
+	return name
+}
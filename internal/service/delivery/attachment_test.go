@@ -0,0 +1,97 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for address, public := range map[string]bool{
+		"8.8.8.8":       true,
+		"2a00:1450::1":  true,
+		"127.0.0.1":     false,
+		"10.1.2.3":      false,
+		"192.168.0.10":  false,
+		"169.254.1.1":   false,
+		"100.64.0.1":    false,
+		"100.127.255.1": false,
+		"0.1.2.3":       false,
+		"::1":           false,
+		"fd00::1":       false,
+	} {
+		if got := isPublicIP(net.ParseIP(address)); got != public {
+			t.Errorf("isPublicIP(%s) = %v, want %v", address, got, public)
+		}
+	}
+}
+
+func TestAttachmentAllowlist(t *testing.T) {
+	allowlist := parseAttachmentAllowlist([]string{"Grafana.corp.example.com", ".monitoring.local", "*.lab.local", "10.20.0.0/16", "192.168.1.5", "bad/cidr"})
+
+	for host, allowed := range map[string]bool{
+		"grafana.corp.example.com":  true,
+		"grafana.corp.example.com.": true,
+		"corp.example.com":          false,
+		"prom.monitoring.local":     true,
+		"a.b.lab.local":             true,
+		"evilmonitoring.local":      false,
+	} {
+		if got := allowlist.allowsHost(host); got != allowed {
+			t.Errorf("allowsHost(%s) = %v, want %v", host, got, allowed)
+		}
+	}
+
+	for address, allowed := range map[string]bool{
+		"10.20.3.4":   true,
+		"10.21.0.1":   false,
+		"192.168.1.5": true,
+		"192.168.1.6": false,
+		"8.8.8.8":     true,
+	} {
+		if got := allowlist.allowsIP(net.ParseIP(address)); got != allowed {
+			t.Errorf("allowsIP(%s) = %v, want %v", address, got, allowed)
+		}
+	}
+}
+
+func TestAttachmentClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("png"))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	cases := []struct {
+		name      string
+		allowlist []string
+		url       string
+		allowed   bool
+	}{
+		{name: "loopback", url: server.URL + "/panel.png"},
+		{name: "allowed address", allowlist: []string{"127.0.0.1"}, url: server.URL + "/panel.png", allowed: true},
+		{name: "allowed host", allowlist: []string{"localhost"}, url: "http://localhost:" + port + "/panel.png", allowed: true},
+		{name: "other host", allowlist: []string{"grafana.local"}, url: "http://localhost:" + port + "/panel.png"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newAttachmentClient(5*time.Second, tc.allowlist)
+			content, name, err := fetchAttachment(context.Background(), client, tc.url)
+			if !tc.allowed {
+				if !errors.Is(err, errForbiddenAddress) {
+					t.Fatalf("err = %v, want errForbiddenAddress", err)
+				}
+				return
+			}
+			if err != nil || string(content) != "png" || name != "panel.png" {
+				t.Fatalf("content = %q, name = %q, err = %v", content, name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Путь: internal/service/delivery/cleaner.go
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/repository/interface"
+)
+
+// Cleaner периодически удаляет записи истории доставок старше срока хранения
+// а также связи ключей корреляции с сообщениями, состояния Alertmanager и ключи дедупликации,
+// срок которых истёк
+type Cleaner struct {
+	repo      _interface.IntegrationRepository
+	retention time.Duration
+	interval  time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewCleaner создает фоновую задачу очистки истории
+func NewCleaner(repo _interface.IntegrationRepository, retention, interval time.Duration) *Cleaner {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &Cleaner{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start запускает очистку в фоне. При retention <= 0 история хранится бессрочно.
+func (c *Cleaner) Start() {
+	if c.retention <= 0 {
+		log.Info().Msg("🧹 Delivery log retention disabled")
+	}
+
+	go func() {
+		defer close(c.done)
+
+		for {
+			c.cleanup()
+
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(c.interval):
+			}
+		}
+	}()
+}
+
+// Stop останавливает очистку и ждёт завершения текущего прохода
+func (c *Cleaner) Stop() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	<-c.done
+}
+
+// cleanup выполняет один проход очистки
+func (c *Cleaner) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if c.retention > 0 {
+		before := time.Now().Add(-c.retention)
+		deleted, err := c.repo.DeleteDeliveryLogsBefore(ctx, before)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to prune delivery logs")
+		} else if deleted > 0 {
+			log.Info().Int64("deleted", deleted).Time("before", before).Msg("🧹 Delivery logs pruned")
+		}
+	}
+
+	if deleted, err := c.repo.DeleteExpiredMessageThreads(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to prune message threads")
+	} else if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("🧹 Expired message threads pruned")
+	}
+
+	if deleted, err := c.repo.DeleteExpiredAlertStates(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to prune alert states")
+	} else if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("🧹 Expired alert states pruned")
+	}
+
+	if deleted, err := c.repo.DeleteExpiredDedupKeys(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to prune dedup keys")
+	} else if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("🧹 Expired dedup keys pruned")
+	}
+}
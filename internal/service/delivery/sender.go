@@ -0,0 +1,261 @@
+// Путь: internal/service/delivery/sender.go
+package delivery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/alertmanager"
+	"yandex-messenger-bridge/internal/service/correlation"
+	"yandex-messenger-bridge/internal/service/encryption"
+	"yandex-messenger-bridge/internal/yandex"
+)
+
+// Sender отправляет одно сообщение в Bot API. Используется воркерами очереди
+// и синхронной проверкой интеграции на последнем вебхуке.
+type Sender struct {
+	repo      _interface.IntegrationRepository
+	encryptor *encryption.Encryptor
+	http      *http.Client // для скачивания вложений, только с публичных адресов и из allowlist
+}
+
+// NewSender создает отправителя. timeout ограничивает скачивание вложений,
+// allowlist - внутренние хосты и подсети, с которых вложения скачивать разрешено.
+func NewSender(repo _interface.IntegrationRepository, encryptor *encryption.Encryptor, timeout time.Duration, allowlist []string) *Sender {
+	return &Sender{
+		repo:      repo,
+		encryptor: encryptor,
+		http:      newAttachmentClient(timeout, allowlist),
+	}
+}
+
+// Send отправляет сообщение от имени бота экземпляра: текст с кнопками, затем вложения.
+// Каждая отправленная часть сохраняется в строке очереди, и повторная попытка продолжает с первой неотправленной.
+// Сообщение с ключом корреляции отвечает в тред или заменяет предыдущее и запоминает себя для следующих;
+// сообщение с CloseThread вместо этого удаляет связь ключа.
+func (s *Sender) Send(ctx context.Context, msg *domain.OutboxMessage) (*yandex.SendResult, error) {
+	instance, err := s.repo.GetInstanceByIDPublic(ctx, msg.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Токен в экземпляре хранится зашифрованным
+	token, err := s.encryptor.Decrypt(instance.BotToken)
+	if err != nil {
+		return nil, errors.New("failed to decrypt bot token")
+	}
+
+	client := yandex.NewClient(token)
+	options := msg.Options
+
+	// Группировка по ключу корреляции: ответ, тред или замена предыдущего сообщения
+	settings, thread, err := s.lookupThread(ctx, instance, msg)
+	if err != nil {
+		return nil, err
+	}
+	if thread != nil {
+		switch settings.Mode {
+		case correlation.ModeReply:
+			if options.ReplyMessageID == 0 && options.ThreadID == 0 {
+				options.ReplyMessageID = thread.MessageID
+			}
+		case correlation.ModeThread:
+			if options.ReplyMessageID == 0 && options.ThreadID == 0 {
+				options.ThreadID = thread.MessageID
+			}
+		case correlation.ModeReplace:
+			// Предыдущее сообщение удалено попыткой, которая уже отправила часть нового
+			if !msg.Progress.IsEmpty() {
+				break
+			}
+			// Сообщение могли удалить вручную - это не повод не отправлять новое
+			if _, err := client.DeleteMessage(ctx, yandex.DeleteMessageRequest{
+				ChatID:    msg.ChatID,
+				Login:     msg.Login,
+				MessageID: thread.MessageID,
+			}); err != nil {
+				log.Warn().Err(err).Int64("outbox_id", msg.ID).Int64("message_id", thread.MessageID).Msg("Failed to delete previous message")
+			}
+		}
+	}
+
+	// Части, отправленные предыдущей попыткой, пропускаются: иначе ретрай после ошибки вложения повторил бы текст
+	progress := &msg.Progress
+	var result *yandex.SendResult
+	if msg.Message != "" && progress.TextMessageID == 0 {
+		result, err = client.SendText(ctx, yandex.SendMessageRequest{
+			ChatID:                msg.ChatID,
+			Login:                 msg.Login,
+			Text:                  msg.Message,
+			ReplyMessageID:        options.ReplyMessageID,
+			ThreadID:              options.ThreadID,
+			DisableWebPagePreview: options.DisableWebPagePreview,
+			InlineKeyboard:        inlineKeyboard(options.Buttons),
+		})
+		if err != nil {
+			return result, err
+		}
+		progress.TextMessageID = result.MessageID
+		s.saveProgress(ctx, msg)
+	}
+
+	if options.ImageURL != "" && progress.ImageMessageID == 0 {
+		content, name, err := fetchAttachment(ctx, s.http, options.ImageURL)
+		if err != nil {
+			return result, err
+		}
+		result, err = client.SendImage(ctx, yandex.SendFileRequest{
+			ChatID:   msg.ChatID,
+			Login:    msg.Login,
+			ThreadID: options.ThreadID,
+			Filename: name,
+			Content:  content,
+		})
+		if err != nil {
+			return result, err
+		}
+		progress.ImageMessageID = result.MessageID
+		s.saveProgress(ctx, msg)
+	}
+
+	if options.FileURL != "" && progress.FileMessageID == 0 {
+		content, name, err := fetchAttachment(ctx, s.http, options.FileURL)
+		if err != nil {
+			return result, err
+		}
+		if options.FileName != "" {
+			name = options.FileName
+		}
+		result, err = client.SendFile(ctx, yandex.SendFileRequest{
+			ChatID:   msg.ChatID,
+			Login:    msg.Login,
+			ThreadID: options.ThreadID,
+			Filename: name,
+			Content:  content,
+		})
+		if err != nil {
+			return result, err
+		}
+		progress.FileMessageID = result.MessageID
+		s.saveProgress(ctx, msg)
+	}
+
+	// Все части отправлены предыдущими попытками (например, под упал до удаления строки из очереди)
+	if result == nil && progress.IsEmpty() {
+		return nil, errors.New("nothing to send: empty text and no attachments")
+	}
+
+	switch {
+	case settings == nil:
+	case msg.CloseThread:
+		s.closeThread(ctx, msg)
+	default:
+		s.saveThread(ctx, msg, settings, thread, progress.FirstMessageID())
+	}
+	return result, nil
+}
+
+// saveProgress запоминает отправленные части сообщения очереди.
+// Ошибка только логируется: часть уже отправлена, и прерывать из-за неё отправку остальных нет смысла.
+// Сообщения проверки на последнем вебхуке в очереди не хранятся (ID = 0).
+func (s *Sender) saveProgress(ctx context.Context, msg *domain.OutboxMessage) {
+	if msg.ID == 0 {
+		return
+	}
+	if err := s.repo.SaveOutboxProgress(ctx, msg.ID, msg.Progress); err != nil {
+		log.Error().Err(err).Int64("outbox_id", msg.ID).Msg("Failed to save outbox progress")
+	}
+}
+
+// lookupThread возвращает настройки группировки и действующую связь ключа с сообщением.
+// Для сообщений без ключа корреляции оба значения nil.
+func (s *Sender) lookupThread(ctx context.Context, instance *domain.IntegrationInstance, msg *domain.OutboxMessage) (*correlation.Settings, *domain.MessageThread, error) {
+	if msg.CorrelationKey == "" {
+		return nil, nil, nil
+	}
+
+	settings, err := threadSettings(instance)
+	if err != nil || settings == nil {
+		// Группировку отключили после постановки сообщения в очередь
+		return nil, nil, err
+	}
+
+	thread, err := s.repo.GetMessageThread(ctx, msg.InstanceID, msg.CorrelationKey, msg.Recipient())
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return settings, thread, nil
+}
+
+// threadSettings возвращает настройки группировки экземпляра.
+// В режиме Alertmanager ключ задаётся уведомлением, а режим - настройками Alertmanager.
+func threadSettings(instance *domain.IntegrationInstance) (*correlation.Settings, error) {
+	am, err := alertmanager.FromCustomSettings(instance.CustomSettings)
+	if err != nil {
+		return nil, err
+	}
+	if am != nil {
+		return am.Correlation(), nil
+	}
+	return correlation.FromCustomSettings(instance.CustomSettings)
+}
+
+// saveThread запоминает сообщение для ключа корреляции и продлевает срок связи.
+// В режимах reply и thread последующие события привязываются к первому сообщению,
+// в режиме replace - к последнему отправленному.
+func (s *Sender) saveThread(ctx context.Context, msg *domain.OutboxMessage, settings *correlation.Settings, thread *domain.MessageThread, sentID int64) {
+	messageID := sentID
+	if thread != nil && settings.Mode != correlation.ModeReplace {
+		messageID = thread.MessageID
+	}
+	if messageID == 0 {
+		return
+	}
+
+	err := s.repo.SaveMessageThread(ctx, &domain.MessageThread{
+		InstanceID:     msg.InstanceID,
+		CorrelationKey: msg.CorrelationKey,
+		ChatID:         msg.Recipient(),
+		MessageID:      messageID,
+		ExpiresAt:      time.Now().Add(settings.Duration()),
+	})
+	if err != nil {
+		// Сообщение уже отправлено: повтор привёл бы к дублю, поэтому только логируем
+		log.Error().Err(err).Int64("outbox_id", msg.ID).Str("correlation_key", msg.CorrelationKey).Msg("Failed to save message thread")
+	}
+}
+
+// closeThread удаляет связь ключа с сообщением после последнего сообщения по ключу (решённая группа Alertmanager):
+// новое срабатывание с тем же ключом начнёт новое сообщение, а не ответит на старое
+func (s *Sender) closeThread(ctx context.Context, msg *domain.OutboxMessage) {
+	if err := s.repo.DeleteMessageThread(ctx, msg.InstanceID, msg.CorrelationKey, msg.Recipient()); err != nil {
+		log.Error().Err(err).Int64("outbox_id", msg.ID).Str("correlation_key", msg.CorrelationKey).Msg("Failed to close message thread")
+	}
+}
+
+// inlineKeyboard преобразует кнопки из шаблона в формат Bot API
+func inlineKeyboard(buttons []domain.MessageButton) []yandex.InlineButton {
+	if len(buttons) == 0 {
+		return nil
+	}
+
+	keyboard := make([]yandex.InlineButton, 0, len(buttons))
+	for _, b := range buttons {
+		keyboard = append(keyboard, yandex.InlineButton{
+			Text:         b.Text,
+			URL:          b.URL,
+			CallbackData: b.CallbackData,
+		})
+	}
+	return keyboard
+}
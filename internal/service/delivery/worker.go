@@ -0,0 +1,236 @@
+// Путь: internal/service/delivery/worker.go
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/encryption"
+	"yandex-messenger-bridge/internal/yandex"
+)
+
+// Config - конфигурация пула воркеров отправки
+type Config struct {
+	Workers      int           // количество параллельных воркеров
+	PollInterval time.Duration // пауза между опросами пустой очереди
+	Lease        time.Duration // время, на которое сообщение захватывается воркером
+	SendTimeout  time.Duration // таймаут одного запроса к Bot API
+	BaseBackoff  time.Duration // задержка перед первой повторной попыткой
+	MaxBackoff   time.Duration // максимальная задержка между попытками
+
+	AttachmentAllowlist []string // внутренние хосты и подсети, с которых можно скачивать вложения
+}
+
+// Worker - пул воркеров, отправляющих сообщения из таблицы delivery_outbox
+type Worker struct {
+	repo      _interface.IntegrationRepository
+	encryptor *encryption.Encryptor
+	config    Config
+	sender    *Sender
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewWorker создает пул воркеров
+func NewWorker(
+	repo _interface.IntegrationRepository,
+	encryptor *encryption.Encryptor,
+	config Config,
+) *Worker {
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = 30 * time.Second
+	}
+	if config.Lease <= config.SendTimeout {
+		config.Lease = 2 * config.SendTimeout
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = 2 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 5 * time.Minute
+	}
+
+	return &Worker{
+		repo:      repo,
+		encryptor: encryptor,
+		config:    config,
+		sender:    NewSender(repo, encryptor, config.SendTimeout, config.AttachmentAllowlist),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start запускает воркеры
+func (w *Worker) Start() {
+	for i := 0; i < w.config.Workers; i++ {
+		w.wg.Add(1)
+		go w.loop()
+	}
+
+	log.Info().Int("workers", w.config.Workers).Msg("📮 Delivery workers started")
+}
+
+// Shutdown прекращает захват новых сообщений и ждёт завершения уже начатых отправок.
+// Если ctx истекает раньше, захваченные сообщения будут подобраны другой репликой после истечения lease.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info().Msg("📮 Delivery workers drained")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop - основной цикл одного воркера
+func (w *Worker) loop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		claimed := w.processNext()
+		if claimed {
+			continue
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.config.PollInterval):
+		}
+	}
+}
+
+// processNext захватывает и отправляет одно сообщение. Возвращает true, если сообщение было.
+func (w *Worker) processNext() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	messages, err := w.repo.ClaimOutbox(ctx, 1, w.config.Lease)
+	cancel()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to claim outbox messages")
+		return false
+	}
+	if len(messages) == 0 {
+		return false
+	}
+
+	for _, msg := range messages {
+		w.deliver(msg)
+	}
+	return true
+}
+
+// deliver отправляет сообщение и фиксирует результат в очереди.
+// Отправка не прерывается при Shutdown, чтобы начатые сообщения не терялись.
+func (w *Worker) deliver(msg *domain.OutboxMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.SendTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	result, err := w.sender.Send(ctx, msg)
+	duration := time.Since(startTime)
+
+	// Результат фиксируем в отдельном контексте: таймаут отправки мог уже истечь
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer saveCancel()
+
+	if err == nil {
+		if err := w.repo.CompleteOutbox(saveCtx, msg.ID); err != nil {
+			log.Error().Err(err).Int64("outbox_id", msg.ID).Msg("Failed to complete outbox message")
+		}
+		w.recordAttempt(saveCtx, msg, domain.DeliveryStatusSent, result, nil, duration)
+		log.Info().
+			Str("instance_id", msg.InstanceID).
+			Int64("outbox_id", msg.ID).
+			Int("attempt", msg.Attempts).
+			Dur("duration", duration).
+			Msg("✅ Message sent successfully")
+		return
+	}
+
+	var nextAttemptAt *time.Time
+	status := domain.DeliveryStatusDead
+	if msg.Attempts < msg.MaxAttempts {
+		next := time.Now().Add(w.backoff(msg.Attempts))
+		nextAttemptAt = &next
+		status = domain.DeliveryStatusFailed
+	}
+
+	if err := w.repo.FailOutbox(saveCtx, msg.ID, err.Error(), nextAttemptAt); err != nil {
+		log.Error().Err(err).Int64("outbox_id", msg.ID).Msg("Failed to record outbox failure")
+	}
+	w.recordAttempt(saveCtx, msg, status, result, err, duration)
+
+	event := log.Error().
+		Err(err).
+		Str("instance_id", msg.InstanceID).
+		Int64("outbox_id", msg.ID).
+		Int("attempt", msg.Attempts).
+		Dur("duration", duration)
+	if nextAttemptAt == nil {
+		event.Msg("❌ Max retries reached, message moved to dead state")
+	} else {
+		event.Time("next_attempt_at", *nextAttemptAt).Msg("❌ Send failed, will retry")
+	}
+}
+
+// recordAttempt сохраняет попытку отправки в историю доставок
+func (w *Worker) recordAttempt(ctx context.Context, msg *domain.OutboxMessage, status string, result *yandex.SendResult, sendErr error, duration time.Duration) {
+	entry := &domain.DeliveryLog{
+		InstanceID:   msg.InstanceID,
+		OutboxID:     &msg.ID,
+		Kind:         domain.DeliveryKindAttempt,
+		Status:       status,
+		RenderedText: &msg.Message,
+		DurationMS:   int(duration.Milliseconds()),
+	}
+	if result != nil {
+		entry.ResponseStatus = &result.StatusCode
+		entry.ResponseBody = &result.Body
+	}
+	if sendErr != nil {
+		errText := sendErr.Error()
+		entry.Error = &errText
+	}
+
+	if err := w.repo.CreateDeliveryLog(ctx, entry); err != nil {
+		log.Error().Err(err).Int64("outbox_id", msg.ID).Msg("Failed to save delivery log")
+	}
+}
+
+// backoff возвращает задержку перед следующей попыткой: 2s, 4s, 8s, ... но не больше MaxBackoff
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := w.config.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= w.config.MaxBackoff {
+			return w.config.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,130 @@
+// Путь: internal/service/diff/diff.go
+package diff
+
+import "strings"
+
+// Операции построчного сравнения
+const (
+	OpEqual  = " "
+	OpInsert = "+"
+	OpDelete = "-"
+)
+
+// maxCells - предел размера таблицы LCS; для больших изменений средняя часть показывается как замена целиком
+const maxCells = 4_000_000
+
+// Line - строка результата сравнения с номерами в старом и новом тексте (0 - строки там нет)
+type Line struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Lines сравнивает тексты построчно по наибольшей общей подпоследовательности
+func Lines(a, b string) []Line {
+	oldLines, newLines := split(a), split(b)
+
+	// Общие начало и конец не участвуют в LCS
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(oldLines)+len(newLines))
+	for i := 0; i < prefix; i++ {
+		result = append(result, Line{Op: OpEqual, Text: oldLines[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	result = append(result, middle(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix], prefix, prefix)...)
+
+	for i := 0; i < suffix; i++ {
+		oldIndex, newIndex := len(oldLines)-suffix+i, len(newLines)-suffix+i
+		result = append(result, Line{Op: OpEqual, Text: oldLines[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+	return result
+}
+
+// Changed сообщает, есть ли в сравнении изменения
+func Changed(lines []Line) bool {
+	for _, line := range lines {
+		if line.Op != OpEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// Unified возвращает сравнение в виде текста с префиксами " ", "+" и "-"
+func Unified(lines []Line) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(line.Op)
+		sb.WriteString(line.Text)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// middle сравнивает отличающуюся часть текстов; oldOffset и newOffset - номера строк перед ней
+func middle(oldLines, newLines []string, oldOffset, newOffset int) []Line {
+	n, m := len(oldLines), len(newLines)
+	var result []Line
+
+	if n*m > maxCells {
+		for i, text := range oldLines {
+			result = append(result, Line{Op: OpDelete, Text: text, OldLine: oldOffset + i + 1})
+		}
+		for j, text := range newLines {
+			result = append(result, Line{Op: OpInsert, Text: text, NewLine: newOffset + j + 1})
+		}
+		return result
+	}
+
+	// lcs[i][j] - длина общей подпоследовательности oldLines[i:] и newLines[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			result = append(result, Line{Op: OpEqual, Text: oldLines[i], OldLine: oldOffset + i + 1, NewLine: newOffset + j + 1})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			result = append(result, Line{Op: OpInsert, Text: newLines[j], NewLine: newOffset + j + 1})
+			j++
+		default:
+			result = append(result, Line{Op: OpDelete, Text: oldLines[i], OldLine: oldOffset + i + 1})
+			i++
+		}
+	}
+	return result
+}
+
+// split делит текст на строки; пустой текст - ноль строк
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
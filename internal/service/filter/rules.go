@@ -0,0 +1,289 @@
+// Путь: internal/service/filter/rules.go
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/osteele/liquid"
+
+	"yandex-messenger-bridge/internal/service/render"
+)
+
+// SettingsKey - ключ правил фильтрации в CustomSettings экземпляра
+const SettingsKey = "filters"
+
+// Типы условий
+const (
+	MatchJSONPath = "json_path" // значение поля тела запроса, например object_attributes.action
+	MatchHeader   = "header"    // значение заголовка, например X-Gitlab-Event
+	MatchLabel    = "label"     // метка: labels GitLab или labels / commonLabels Alertmanager
+	MatchRegex    = "regex"     // регулярное выражение по всему телу запроса
+	MatchLiquid   = "liquid"    // логическое Liquid-выражение, например issue.fields.priority.name == "Highest"
+)
+
+// Действия правила
+const (
+	ActionSend = "send"
+	ActionDrop = "drop"
+)
+
+// Condition - условие над входящим событием
+type Condition struct {
+	Match   string `json:"match"`
+	Path    string `json:"path,omitempty"`    // для json_path
+	Header  string `json:"header,omitempty"`  // для header
+	Label   string `json:"label,omitempty"`   // для label: имя метки
+	Pattern string `json:"pattern,omitempty"` // регулярное выражение для json_path, header, label и regex; пустое - поле просто есть
+	Expr    string `json:"expr,omitempty"`    // для liquid
+}
+
+// Rule - правило фильтрации. Правила проверяются по порядку, срабатывает первое совпавшее.
+type Rule struct {
+	Condition
+	Action string `json:"action"`
+}
+
+// Settings - правила фильтрации входящих событий экземпляра
+type Settings struct {
+	Rules   []Rule `json:"rules"`
+	Default string `json:"default"` // действие, если ни одно правило не совпало (по умолчанию send)
+}
+
+// FromCustomSettings извлекает правила фильтрации из CustomSettings экземпляра.
+// Возвращает nil, если правил нет.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid filter settings: %w", err)
+	}
+	if len(s.Rules) == 0 && s.Default != ActionDrop {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет правила в CustomSettings. Без правил и с действием send настройки удаляются.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || (len(s.Rules) == 0 && s.Default != ActionDrop) {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// Validate проверяет правила, компилируя регулярные выражения и Liquid-выражения
+func (s *Settings) Validate() error {
+	_, err := s.Compile(render.Engine())
+	return err
+}
+
+// Input - входящее событие, к которому применяются правила
+type Input struct {
+	Header http.Header
+	Body   []byte
+	Data   map[string]interface{}
+}
+
+// Filter - скомпилированные правила
+type Filter struct {
+	rules    []compiledRule
+	fallback string
+}
+
+type compiledRule struct {
+	*Matcher
+	action string
+}
+
+// Matcher - скомпилированное условие
+type Matcher struct {
+	Condition
+	pattern *regexp.Regexp
+	expr    *liquid.Template
+}
+
+// Compile проверяет и компилирует правила
+func (s *Settings) Compile(engine *liquid.Engine) (*Filter, error) {
+	f := &Filter{fallback: s.Default}
+	switch f.fallback {
+	case "":
+		f.fallback = ActionSend
+	case ActionSend, ActionDrop:
+	default:
+		return nil, fmt.Errorf("unknown default action %q", s.Default)
+	}
+
+	for i, rule := range s.Rules {
+		switch rule.Action {
+		case ActionSend, ActionDrop:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown action %q", i+1, rule.Action)
+		}
+
+		matcher, err := rule.Condition.Compile(engine)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		f.rules = append(f.rules, compiledRule{Matcher: matcher, action: rule.Action})
+	}
+	return f, nil
+}
+
+// Evaluate возвращает действие для события и номер сработавшего правила (0 - действие по умолчанию)
+func (f *Filter) Evaluate(in Input) (string, int, error) {
+	for i, rule := range f.rules {
+		matched, err := rule.Matches(in)
+		if err != nil {
+			return "", i + 1, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if matched {
+			return rule.action, i + 1, nil
+		}
+	}
+	return f.fallback, 0, nil
+}
+
+// Compile проверяет и компилирует условие
+func (c Condition) Compile(engine *liquid.Engine) (*Matcher, error) {
+	m := &Matcher{Condition: c}
+
+	switch c.Match {
+	case MatchJSONPath:
+		if strings.TrimSpace(c.Path) == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+	case MatchHeader:
+		if strings.TrimSpace(c.Header) == "" {
+			return nil, fmt.Errorf("header is required")
+		}
+	case MatchLabel:
+		if strings.TrimSpace(c.Label) == "" {
+			return nil, fmt.Errorf("label is required")
+		}
+	case MatchRegex:
+		if c.Pattern == "" {
+			return nil, fmt.Errorf("pattern is required")
+		}
+	case MatchLiquid:
+		if strings.TrimSpace(c.Expr) == "" {
+			return nil, fmt.Errorf("expression is required")
+		}
+		tpl, err := engine.ParseString("{% if " + c.Expr + " %}true{% endif %}")
+		if err != nil {
+			return nil, fmt.Errorf("invalid liquid expression: %w", err)
+		}
+		m.expr = tpl
+	default:
+		return nil, fmt.Errorf("unknown match type %q", c.Match)
+	}
+
+	if c.Pattern != "" && c.Match != MatchLiquid {
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		m.pattern = re
+	}
+	return m, nil
+}
+
+// Matches проверяет условие на событии
+func (m *Matcher) Matches(in Input) (bool, error) {
+	switch m.Match {
+	case MatchJSONPath:
+		value, ok := render.Lookup(in.Data, m.Path)
+		if !ok || value == nil {
+			return false, nil
+		}
+		return m.matchString(stringify(value)), nil
+	case MatchHeader:
+		values := in.Header.Values(m.Header)
+		if len(values) == 0 {
+			return false, nil
+		}
+		return m.matchString(strings.Join(values, ", ")), nil
+	case MatchLabel:
+		for _, value := range labelValues(in.Data, m.Label) {
+			if m.matchString(value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case MatchRegex:
+		return m.pattern.Match(in.Body), nil
+	case MatchLiquid:
+		out, err := m.expr.RenderString(in.Data)
+		if err != nil {
+			return false, err
+		}
+		return strings.TrimSpace(out) == "true", nil
+	}
+	return false, nil
+}
+
+// matchString сравнивает значение с шаблоном; без шаблона достаточно наличия значения
+func (m *Matcher) matchString(value string) bool {
+	if m.pattern == nil {
+		return true
+	}
+	return m.pattern.MatchString(value)
+}
+
+// labelPaths - где искать метки: Alertmanager (alert и группа) и GitLab
+var labelPaths = []string{"alert.labels", "commonLabels", "groupLabels", "labels", "object_attributes.labels"}
+
+// labelValues возвращает значения метки name. Метки-словари (Alertmanager) дают значение по ключу,
+// метки-списки (GitLab: [{title: ...}]) - title, если он совпадает с name.
+func labelValues(data map[string]interface{}, name string) []string {
+	var values []string
+	for _, path := range labelPaths {
+		node, ok := render.Lookup(data, path)
+		if !ok {
+			continue
+		}
+		switch labels := node.(type) {
+		case map[string]interface{}:
+			if value, ok := labels[name]; ok && value != nil {
+				values = append(values, stringify(value))
+			}
+		case []interface{}:
+			for _, item := range labels {
+				title := item
+				if obj, ok := item.(map[string]interface{}); ok {
+					title = obj["title"]
+				}
+				if s, ok := title.(string); ok && s == name {
+					values = append(values, s)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// stringify приводит значение из JSON к строке для сравнения с шаблоном
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
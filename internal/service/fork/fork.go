@@ -0,0 +1,53 @@
+// Путь: internal/service/fork/fork.go
+package fork
+
+import (
+	"context"
+	"fmt"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+)
+
+// Shared сообщает, что шаблон нельзя менять из интеграции пользователя userID:
+// он публичный (им пользуются другие) или принадлежит другому пользователю.
+// Правка такого шаблона из интеграции сохраняется в личную копию.
+func Shared(template *domain.Template, userID string) bool {
+	return template.IsPublic || !template.CreatedBy.Valid || template.CreatedBy.String != userID
+}
+
+// Instance создает личную копию шаблона экземпляра с текстом text вместе с примерами данных
+// и переключает на неё экземпляр. Экземпляр нужно сохранить через UpdateInstance.
+// Исходный шаблон и его версия записываются в копию для последующего переноса обновлений.
+func Instance(ctx context.Context, repo _interface.IntegrationRepository, instance *domain.IntegrationInstance, userID, text string) (*domain.Template, error) {
+	original := instance.Template
+	if original == nil {
+		return nil, fmt.Errorf("instance has no template")
+	}
+
+	samples, err := repo.ListTemplateSamples(ctx, original.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template samples: %w", err)
+	}
+
+	copied := original.Fork(userID)
+	copied.TemplateText = text
+	if err := repo.CreateTemplate(ctx, copied); err != nil {
+		return nil, fmt.Errorf("failed to create template copy: %w", err)
+	}
+
+	// Тесты копии ссылаются на примеры по имени, поэтому примеры копируются вместе с шаблоном
+	for _, sample := range samples {
+		s := *sample
+		s.TemplateID = copied.ID
+		if err := repo.SaveTemplateSample(ctx, &s); err != nil {
+			return nil, fmt.Errorf("failed to copy template sample %s: %w", sample.Name, err)
+		}
+		copied.Samples = append(copied.Samples, &s)
+	}
+
+	instance.TemplateID = copied.ID
+	instance.Template = copied
+	instance.TemplateVersion = nil
+	return copied, nil
+}
@@ -0,0 +1,88 @@
+// Путь: internal/service/message/frontmatter.go
+package message
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// frontMatterDelimiter - строка, открывающая и закрывающая блок параметров
+const frontMatterDelimiter = "---"
+
+// Parse отделяет от результата рендеринга блок front matter с параметрами сообщения:
+//
+//	---
+//	buttons:
+//	  - text: Открыть MR
+//	    url: https://gitlab.example.com/...
+//	image_url: https://grafana.example.com/render/...
+//	---
+//	Текст сообщения
+//
+// Если блока нет, весь результат считается текстом.
+func Parse(rendered string) (string, domain.MessageOptions, error) {
+	var options domain.MessageOptions
+
+	trimmed := strings.TrimLeft(rendered, " \t\r\n")
+	firstLine, rest, found := strings.Cut(trimmed, "\n")
+	if !found || strings.TrimSpace(firstLine) != frontMatterDelimiter {
+		return rendered, options, nil
+	}
+
+	header, body, ok := cutClosingDelimiter(rest)
+	if !ok {
+		return "", options, fmt.Errorf("front matter is not closed with %q", frontMatterDelimiter)
+	}
+
+	if strings.TrimSpace(header) != "" {
+		decoder := yaml.NewDecoder(strings.NewReader(header))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&options); err != nil {
+			return "", options, fmt.Errorf("invalid front matter: %w", err)
+		}
+	}
+
+	if err := validate(options); err != nil {
+		return "", options, err
+	}
+
+	return strings.TrimSpace(body), options, nil
+}
+
+// IsBlank сообщает, что после рендеринга отправлять нечего: нет ни текста, ни вложений
+func IsBlank(text string, options domain.MessageOptions) bool {
+	return strings.TrimSpace(text) == "" && options.ImageURL == "" && options.FileURL == ""
+}
+
+// cutClosingDelimiter делит текст по первой строке, состоящей из разделителя
+func cutClosingDelimiter(text string) (header, body string, ok bool) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == frontMatterDelimiter {
+			return strings.Join(lines[:i], "\n"), strings.Join(lines[i+1:], "\n"), true
+		}
+	}
+	return "", "", false
+}
+
+// validate проверяет параметры, которые Bot API иначе отклонит
+func validate(options domain.MessageOptions) error {
+	for i, button := range options.Buttons {
+		if button.Text == "" {
+			return fmt.Errorf("button %d: text is required", i+1)
+		}
+		if button.URL == "" && len(button.CallbackData) == 0 {
+			return fmt.Errorf("button %q: url or callback_data is required", button.Text)
+		}
+	}
+	for _, u := range []string{options.ImageURL, options.FileURL} {
+		if u != "" && !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return fmt.Errorf("attachment url must be http(s): %q", u)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Путь: internal/service/payload/context.go
+package payload
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Служебные ключи контекста шаблона. Префикс _ исключает пересечение с полями тела.
+const (
+	RawKey     = "_raw"     // тело запроса строкой
+	HeadersKey = "_headers" // заголовки запроса
+	QueryKey   = "_query"   // параметры строки запроса
+	MetaKey    = "_meta"    // сведения об экземпляре и времени получения
+)
+
+// BridgeKey - пространство имён с переменными запроса: bridge.headers, bridge.query, bridge.instance, bridge.now.
+// Присутствует всегда и перекрывает одноимённое поле тела.
+const BridgeKey = "bridge"
+
+// Короткие имена переменных запроса на верхнем уровне контекста.
+// Добавляются, только если в теле нет поля с таким именем, - иначе доступны через bridge.
+const (
+	HeadersAlias  = "headers"
+	QueryAlias    = "query"
+	InstanceAlias = "instance"
+	NowAlias      = "now"
+)
+
+// Meta - сведения о запросе и экземпляре, доступные шаблону
+type Meta struct {
+	InstanceID   string
+	InstanceName string
+	ChatID       string
+	TemplateName string
+	ReceivedAt   time.Time
+	ContentType  string
+}
+
+// Enrich добавляет в данные переменные запроса: служебные ключи _raw, _headers, _query, _meta,
+// пространство имён bridge и короткие имена headers, query, instance, now.
+// Заголовки доступны по каноническому имени и в нижнем регистре: {{ headers["X-Gitlab-Event"] }}.
+func Enrich(data map[string]interface{}, body []byte, header http.Header, query url.Values, meta Meta) {
+	headers := make(map[string]interface{}, len(header)*2)
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		headers[http.CanonicalHeaderKey(name)] = value
+		headers[strings.ToLower(name)] = value
+	}
+	params := Values(query)
+	receivedAt := meta.ReceivedAt.Format(time.RFC3339)
+	instance := map[string]interface{}{
+		"id":       meta.InstanceID,
+		"name":     meta.InstanceName,
+		"chat_id":  meta.ChatID,
+		"template": meta.TemplateName,
+	}
+
+	data[RawKey] = string(body)
+	data[HeadersKey] = headers
+	data[QueryKey] = params
+	data[MetaKey] = map[string]interface{}{
+		"instance_id":   meta.InstanceID,
+		"instance_name": meta.InstanceName,
+		"received_at":   receivedAt,
+		"content_type":  meta.ContentType,
+	}
+
+	vars := map[string]interface{}{
+		HeadersAlias:  headers,
+		QueryAlias:    params,
+		InstanceAlias: instance,
+		NowAlias:      receivedAt,
+	}
+	data[BridgeKey] = vars
+	for name, value := range vars {
+		if _, exists := data[name]; !exists {
+			data[name] = value
+		}
+	}
+}
+
+// IsRequestKey сообщает, является ли ключ контекста служебным (_raw, _headers, _query, _meta, bridge), а не полем тела
+func IsRequestKey(key string) bool {
+	switch key {
+	case RawKey, HeadersKey, QueryKey, MetaKey, BridgeKey:
+		return true
+	}
+	return false
+}
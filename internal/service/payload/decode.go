@@ -0,0 +1,213 @@
+// Путь: internal/service/payload/decode.go
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+)
+
+// Ключи, под которыми в контекст шаблона попадают тела, не являющиеся JSON-объектом
+const (
+	ItemsKey = "items" // JSON-массив верхнего уровня
+	ValueKey = "value" // JSON-скаляр верхнего уровня
+	TextKey  = "text"  // текст без известного формата
+)
+
+// maxMultipartMemory - сколько данных multipart держать в памяти (тело уже прочитано целиком)
+const maxMultipartMemory = 32 << 20
+
+// Decode разбирает тело запроса в данные для Liquid согласно Content-Type:
+//
+//   - application/json и *+json: объект как есть, массив - в items, скаляр - в value;
+//   - application/x-www-form-urlencoded: поля формы, повторяющиеся - списком; JSON-объект - как JSON
+//     (curl -d отправляет JSON с этим Content-Type, и такие вебхуки всегда разбирались как JSON);
+//   - multipart/form-data: поля формы, файлы - {filename, content_type, size};
+//   - application/xml, text/xml и *+xml: дерево элементов, атрибуты - с префиксом @;
+//   - остальное - текст в text.
+//
+// Без Content-Type тело разбирается как JSON, если это корректный JSON, иначе как текст.
+func Decode(contentType string, body []byte) (map[string]interface{}, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return decodeJSON(body)
+	case mediaType == "application/x-www-form-urlencoded" && isJSONObject(body):
+		return decodeJSON(body)
+	case mediaType == "application/x-www-form-urlencoded":
+		return decodeForm(body)
+	case mediaType == "multipart/form-data":
+		return decodeMultipart(body, params["boundary"])
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return decodeXML(body)
+	case mediaType == "" && json.Valid(body):
+		return decodeJSON(body)
+	default:
+		return map[string]interface{}{TextKey: string(body)}, nil
+	}
+}
+
+// decodeJSON разбирает JSON любого вида
+func decodeJSON(body []byte) (map[string]interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		return map[string]interface{}{ItemsKey: v}, nil
+	default:
+		return map[string]interface{}{ValueKey: v}, nil
+	}
+}
+
+// isJSONObject сообщает, что тело - корректный JSON-объект
+func isJSONObject(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed)
+}
+
+// decodeForm разбирает application/x-www-form-urlencoded
+func decodeForm(body []byte) (map[string]interface{}, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form: %w", err)
+	}
+	return Values(values), nil
+}
+
+// decodeMultipart разбирает multipart/form-data
+func decodeMultipart(body []byte, boundary string) (map[string]interface{}, error) {
+	if boundary == "" {
+		return nil, errors.New("invalid multipart: boundary is missing")
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(maxMultipartMemory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart: %w", err)
+	}
+	defer form.RemoveAll()
+
+	data := Values(form.Value)
+	for name, files := range form.File {
+		list := make([]interface{}, 0, len(files))
+		for _, file := range files {
+			list = append(list, map[string]interface{}{
+				"filename":     file.Filename,
+				"content_type": file.Header.Get("Content-Type"),
+				"size":         file.Size,
+			})
+		}
+		if len(list) == 1 {
+			data[name] = list[0]
+		} else {
+			data[name] = list
+		}
+	}
+	return data, nil
+}
+
+// Values переводит параметры формы или запроса в данные для Liquid: одно значение - строкой, несколько - списком
+func Values(values url.Values) map[string]interface{} {
+	data := make(map[string]interface{}, len(values))
+	for name, list := range values {
+		if len(list) == 1 {
+			data[name] = list[0]
+			continue
+		}
+		items := make([]interface{}, 0, len(list))
+		for _, v := range list {
+			items = append(items, v)
+		}
+		data[name] = items
+	}
+	return data
+}
+
+// decodeXML переводит XML-документ в дерево: {корень: {элемент: ..., "@атрибут": ...}}.
+// Элемент без дочерних элементов и атрибутов становится строкой, повторяющиеся элементы - списком,
+// текст рядом с дочерними элементами - полем #text.
+func decodeXML(body []byte) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("invalid XML: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			root, err := decodeElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("invalid XML: %w", err)
+			}
+			return map[string]interface{}{start.Name.Local: root}, nil
+		}
+	}
+}
+
+// decodeElement разбирает элемент до его закрывающего тега
+func decodeElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	node := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		node["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			addChild(node, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(node) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				node["#text"] = content
+			}
+			return node, nil
+		}
+	}
+}
+
+// addChild добавляет дочерний элемент; повторяющиеся элементы собираются в список
+func addChild(node map[string]interface{}, name string, child interface{}) {
+	existing, ok := node[name]
+	if !ok {
+		node[name] = child
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		node[name] = append(list, child)
+		return
+	}
+	node[name] = []interface{}{existing, child}
+}
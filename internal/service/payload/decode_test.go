@@ -0,0 +1,76 @@
+package payload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        map[string]interface{}
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"status":"ok","count":2}`,
+			want:        map[string]interface{}{"status": "ok", "count": float64(2)},
+		},
+		{
+			name:        "json array",
+			contentType: "application/vnd.api+json",
+			body:        `[1,2]`,
+			want:        map[string]interface{}{ItemsKey: []interface{}{float64(1), float64(2)}},
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "status=ok&tag=a&tag=b",
+			want:        map[string]interface{}{"status": "ok", "tag": []interface{}{"a", "b"}},
+		},
+		{
+			// curl -d '{...}' отправляет JSON с Content-Type формы
+			name:        "json object sent as form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        ` {"object_kind":"push","user_name":"Иван"}`,
+			want:        map[string]interface{}{"object_kind": "push", "user_name": "Иван"},
+		},
+		{
+			name:        "form field that looks like json",
+			contentType: "application/x-www-form-urlencoded",
+			body:        `payload={"a":1}`,
+			want:        map[string]interface{}{"payload": `{"a":1}`},
+		},
+		{
+			name: "no content type",
+			body: `{"a":"b"}`,
+			want: map[string]interface{}{"a": "b"},
+		},
+		{
+			name:        "text",
+			contentType: "text/plain",
+			body:        `{"a":"b"}`,
+			want:        map[string]interface{}{TextKey: `{"a":"b"}`},
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        `<alert level="high"><name>disk</name></alert>`,
+			want:        map[string]interface{}{"alert": map[string]interface{}{"@level": "high", "name": "disk"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Decode(tc.contentType, []byte(tc.body))
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
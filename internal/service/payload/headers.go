@@ -0,0 +1,49 @@
+// Путь: internal/service/payload/headers.go
+package payload
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RedactedValue заменяет значение заголовка с секретом в сохраняемых копиях запроса
+const RedactedValue = "[redacted]"
+
+// secretMarkers - части имён заголовков, которые могут содержать секреты (X-Gitlab-Token, X-Hub-Signature, ...)
+var secretMarkers = []string{"auth", "cookie", "token", "secret", "signature", "key", "password"}
+
+// IsSecretHeader сообщает, может ли заголовок содержать секрет
+func IsSecretHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, marker := range secretMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders возвращает копию заголовков, в которой значения заголовков с секретами заменены на RedactedValue.
+// Имена остаются, чтобы в истории было видно, что заголовок пришёл.
+func RedactHeaders(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if IsSecretHeader(name) {
+			redacted[name] = []string{RedactedValue}
+			continue
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// RedactStoredHeaders скрывает секреты в заголовках, сохранённых в JSONB (last_webhook_headers)
+func RedactStoredHeaders(raw json.RawMessage) json.RawMessage {
+	header := http.Header{}
+	if len(raw) == 0 || json.Unmarshal(raw, &header) != nil {
+		return nil
+	}
+	redacted, _ := json.Marshal(RedactHeaders(header))
+	return redacted
+}
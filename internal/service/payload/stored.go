@@ -0,0 +1,85 @@
+// Путь: internal/service/payload/stored.go
+package payload
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"unicode/utf8"
+)
+
+// Кодировки тела не в формате JSON в колонке JSONB
+const (
+	EncodingText   = "text"
+	EncodingBase64 = "base64"
+)
+
+// storedBody - обёртка для тела не в формате JSON: {"encoding": "text", "body": "..."}.
+// Кодировка указывается явно, чтобы тело-JSON-строка ("abc" с application/json) не путалось с текстом abc.
+type storedBody struct {
+	Encoding string `json:"encoding"`
+	Body     string `json:"body"`
+}
+
+// Stored восстанавливает тело и заголовки последнего вебхука экземпляра
+// (поля last_webhook_body и last_webhook_headers) или примера шаблона
+func Stored(headers, body json.RawMessage) ([]byte, http.Header) {
+	header := http.Header{}
+	if len(headers) > 0 {
+		json.Unmarshal(headers, &header)
+	}
+
+	if wrapped, ok := unwrap(body); ok {
+		return wrapped, header
+	}
+	return []byte(body), header
+}
+
+// StoredBody готовит тело запроса к сохранению в колонку JSONB: JSON хранится как есть,
+// остальное - в обёртке с кодировкой text (или base64 для двоичных данных)
+func StoredBody(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		// JSON, совпадающий по виду с обёрткой, тоже оборачивается, иначе его не отличить от текста
+		if _, ok := unwrap(body); !ok {
+			return body
+		}
+	}
+
+	wrapped := storedBody{Encoding: EncodingText, Body: string(body)}
+	if !utf8.Valid(body) {
+		wrapped = storedBody{Encoding: EncodingBase64, Body: base64.StdEncoding.EncodeToString(body)}
+	}
+	stored, _ := json.Marshal(wrapped)
+	return stored
+}
+
+// unwrap возвращает тело из обёртки; ok = false, если body - обычный JSON
+func unwrap(body json.RawMessage) ([]byte, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil || len(fields) != 2 {
+		return nil, false
+	}
+	var wrapped storedBody
+	if json.Unmarshal(fields["encoding"], &wrapped.Encoding) != nil || json.Unmarshal(fields["body"], &wrapped.Body) != nil {
+		return nil, false
+	}
+
+	switch wrapped.Encoding {
+	case EncodingText:
+		return []byte(wrapped.Body), true
+	case EncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(wrapped.Body)
+		if err != nil {
+			return nil, false
+		}
+		return data, true
+	default:
+		return nil, false
+	}
+}
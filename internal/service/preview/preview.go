@@ -0,0 +1,165 @@
+// Путь: internal/service/preview/preview.go
+package preview
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/alertmanager"
+	"yandex-messenger-bridge/internal/service/message"
+	"yandex-messenger-bridge/internal/service/payload"
+	"yandex-messenger-bridge/internal/service/render"
+	"yandex-messenger-bridge/internal/service/samples"
+)
+
+// Источники данных для предпросмотра
+const (
+	SourceLastWebhook = "last_webhook" // последний вебхук экземпляра
+	SourceSample      = "sample"       // именованный пример данных шаблона
+	SourcePayload     = "payload"      // данные, вставленные в редакторе
+)
+
+// Ошибки подготовки данных
+var (
+	ErrNoLastWebhook = errors.New("no webhooks received yet")
+	ErrNoSample      = errors.New("template has no such sample")
+	ErrNoPayload     = errors.New("payload is empty")
+	ErrNoAlerts      = errors.New("alertmanager notification has no alerts")
+)
+
+// Result - результат предпросмотра
+type Result struct {
+	Source   string                `json:"source"`
+	Sample   string                `json:"sample,omitempty"` // имя примера для source=sample
+	Rendered string                `json:"rendered"`         // результат рендеринга целиком, вместе с front matter
+	Text     string                `json:"text"`             // текст сообщения
+	Options  domain.MessageOptions `json:"options"`          // кнопки и вложения из front matter
+	Blank    bool                  `json:"blank"`            // пустой результат: сообщение не будет отправлено
+	Error    *render.Error         `json:"error,omitempty"`
+}
+
+// DefaultSource выбирает источник данных по умолчанию: последний вебхук, пример шаблона, вставленные данные.
+// Примеры шаблона должны быть загружены в template.Samples.
+func DefaultSource(instance *domain.IntegrationInstance, template *domain.Template) string {
+	switch {
+	case instance != nil && instance.LastWebhookAt != nil:
+		return SourceLastWebhook
+	case template != nil && len(template.Samples) > 0:
+		return SourceSample
+	default:
+		return SourcePayload
+	}
+}
+
+// Preview рендерит текст шаблона на данных из source. sample - имя или ID примера шаблона
+// (пустое - первый пример). instance и template могут быть nil (предпросмотр в редакторе шаблона без экземпляра).
+func Preview(text, source, sample, pasted string, instance *domain.IntegrationInstance, template *domain.Template) Result {
+	if source == "" {
+		source = DefaultSource(instance, template)
+	}
+	if source == SourceSample && template != nil {
+		if found := samples.Find(template.Samples, sample); found != nil {
+			sample = found.Name
+		}
+	}
+
+	data, err := Data(source, sample, pasted, instance, template)
+	if err != nil {
+		return Result{Source: source, Sample: sample, Error: &render.Error{Message: err.Error()}}
+	}
+
+	result := Run(text, data)
+	result.Source, result.Sample = source, sample
+	return result
+}
+
+// Data готовит контекст шаблона так же, как обработчик вебхуков: разбор тела по Content-Type
+// и переменные запроса. В режиме Alertmanager берётся первое событие.
+func Data(source, sample, pasted string, instance *domain.IntegrationInstance, template *domain.Template) (map[string]interface{}, error) {
+	var body []byte
+	header := http.Header{}
+	receivedAt := time.Now()
+
+	switch source {
+	case SourceLastWebhook:
+		if instance == nil || instance.LastWebhookAt == nil {
+			return nil, ErrNoLastWebhook
+		}
+		body, header = payload.Stored(instance.LastWebhookHeaders, instance.LastWebhookBody)
+		receivedAt = *instance.LastWebhookAt
+	case SourceSample:
+		if template == nil {
+			return nil, ErrNoSample
+		}
+		found := samples.Find(template.Samples, sample)
+		if found == nil {
+			return nil, ErrNoSample
+		}
+		body, header = samples.Body(found)
+	case SourcePayload:
+		if pasted == "" {
+			return nil, ErrNoPayload
+		}
+		body = []byte(pasted)
+	default:
+		return nil, errors.New("unknown preview source: " + source)
+	}
+
+	return Prepare(body, header, receivedAt, instance, template)
+}
+
+// Prepare разбирает тело по Content-Type из header и добавляет переменные запроса.
+// В режиме Alertmanager экземпляра возвращается первое событие.
+func Prepare(body []byte, header http.Header, receivedAt time.Time, instance *domain.IntegrationInstance, template *domain.Template) (map[string]interface{}, error) {
+	contentType := header.Get("Content-Type")
+	data, err := payload.Decode(contentType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := payload.Meta{ReceivedAt: receivedAt, ContentType: contentType}
+	if instance != nil {
+		meta.InstanceID, meta.InstanceName, meta.ChatID = instance.ID, instance.Name, instance.ChatID
+	}
+	if template != nil {
+		meta.TemplateName = template.Name
+	}
+	payload.Enrich(data, body, header, nil, meta)
+
+	if instance == nil {
+		return data, nil
+	}
+	settings, err := alertmanager.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return data, err
+	}
+	events, err := settings.Events(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrNoAlerts
+	}
+	return events[0].Data, nil
+}
+
+// Run рендерит шаблон и разбирает front matter, как при отправке
+func Run(text string, data map[string]interface{}) Result {
+	rendered, renderErr := render.Preview(text, data)
+	if renderErr != nil {
+		return Result{Error: renderErr}
+	}
+
+	result := Result{Rendered: rendered}
+	body, options, err := message.Parse(rendered)
+	if err != nil {
+		result.Error = &render.Error{Message: err.Error()}
+		return result
+	}
+
+	result.Text, result.Options = body, options
+	result.Blank = message.IsBlank(body, options)
+	return result
+}
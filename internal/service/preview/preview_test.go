@@ -0,0 +1,65 @@
+package preview
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/alertmanager"
+)
+
+const alertmanagerBody = `{"version":"4","groupKey":"{}:{alertname=\"DiskFull\"}","status":"firing",
+"alerts":[
+  {"status":"firing","labels":{"alertname":"DiskFull","instance":"db-1"},"startsAt":"2024-05-01T10:00:00Z","fingerprint":"a1"},
+  {"status":"firing","labels":{"alertname":"DiskFull","instance":"db-2"},"startsAt":"2024-05-01T10:00:00Z","fingerprint":"a2"}
+]}`
+
+var jsonHeader = http.Header{"Content-Type": {"application/json"}}
+
+func TestPrepareWithoutAlertmanager(t *testing.T) {
+	for name, custom := range map[string]map[string]interface{}{
+		"no settings":    nil,
+		"other settings": {"dedup": map[string]interface{}{"enabled": true}},
+		"disabled":       {alertmanager.SettingsKey: map[string]interface{}{"enabled": false}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			instance := &domain.IntegrationInstance{ID: "instance-1", Name: "Мониторинг", CustomSettings: custom}
+
+			data, err := Prepare([]byte(alertmanagerBody), jsonHeader, time.Now(), instance, nil)
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if alerts, _ := data["alerts"].([]interface{}); len(alerts) != 2 {
+				t.Fatalf("alerts = %v, want the whole notification", data["alerts"])
+			}
+			if _, ok := data["alert"]; ok {
+				t.Fatal("notification was split into alerts")
+			}
+		})
+	}
+}
+
+func TestPrepareAlertmanager(t *testing.T) {
+	instance := &domain.IntegrationInstance{
+		ID: "instance-1",
+		CustomSettings: map[string]interface{}{
+			alertmanager.SettingsKey: map[string]interface{}{"enabled": true, "split": alertmanager.SplitAlert},
+		},
+	}
+
+	data, err := Prepare([]byte(alertmanagerBody), jsonHeader, time.Now(), instance, nil)
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	alert, _ := data["alert"].(map[string]interface{})
+	if alert["fingerprint"] != "a1" {
+		t.Fatalf("alert = %v, want the first alert", data["alert"])
+	}
+
+	empty := `{"version":"4","groupKey":"g","status":"firing","alerts":[]}`
+	if _, err := Prepare([]byte(empty), jsonHeader, time.Now(), instance, nil); !errors.Is(err, ErrNoAlerts) {
+		t.Fatalf("err = %v, want ErrNoAlerts", err)
+	}
+}
@@ -0,0 +1,153 @@
+// Путь: internal/service/recipients/settings.go
+package recipients
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/osteele/liquid"
+
+	"yandex-messenger-bridge/internal/service/render"
+)
+
+// SettingsKey - ключ настроек личных сообщений в CustomSettings экземпляра
+const SettingsKey = "direct"
+
+// Settings - доставка личных сообщений пользователям, найденным в теле вебхука
+type Settings struct {
+	Enabled    bool              `json:"enabled"`
+	Recipients string            `json:"recipients"`          // Liquid-выражение, дающее имена или email через запятую или перевод строки
+	Logins     map[string]string `json:"logins,omitempty"`    // имя в источнике → логин Яндекс 360
+	Domain     string            `json:"domain,omitempty"`    // домен для имён без @ и без соответствия: jdoe → jdoe@<domain>
+	KeepChat   bool              `json:"keep_chat,omitempty"` // отправлять и в чат интеграции
+}
+
+// FromCustomSettings извлекает настройки личных сообщений из CustomSettings экземпляра.
+// Возвращает nil, если режим не включён.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid direct message settings: %w", err)
+	}
+	if !s.Enabled {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет настройки в CustomSettings. nil или выключенный режим удаляют настройки.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || !s.Enabled {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// Validate проверяет корректность настроек
+func (s *Settings) Validate() error {
+	if strings.TrimSpace(s.Recipients) == "" {
+		return fmt.Errorf("recipients expression is required")
+	}
+	if _, err := render.Engine().ParseString(s.Recipients); err != nil {
+		return fmt.Errorf("invalid recipients expression: %w", err)
+	}
+	if strings.Contains(s.Domain, "@") {
+		return fmt.Errorf("domain must not contain @")
+	}
+	return nil
+}
+
+// Resolve вычисляет логины получателей для данных вебхука.
+// Имя заменяется по таблице соответствий; email используется как есть; имя без @ дополняется доменом.
+// Имена, для которых логин определить нельзя, возвращаются вторым значением.
+func (s *Settings) Resolve(engine *liquid.Engine, data map[string]interface{}) ([]string, []string, error) {
+	out, err := engine.ParseAndRenderString(s.Recipients, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render recipients: %w", err)
+	}
+
+	var logins, unresolved []string
+	seen := make(map[string]bool)
+	for _, name := range Split(out) {
+		login := s.login(name)
+		if login == "" {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		if !seen[login] {
+			seen[login] = true
+			logins = append(logins, login)
+		}
+	}
+	return logins, unresolved, nil
+}
+
+// login возвращает логин Яндекс 360 для имени из источника
+func (s *Settings) login(name string) string {
+	if login, ok := s.Logins[name]; ok {
+		return strings.TrimSpace(login)
+	}
+	if login, ok := s.Logins[strings.ToLower(name)]; ok {
+		return strings.TrimSpace(login)
+	}
+	if strings.Contains(name, "@") {
+		return name
+	}
+	if s.Domain != "" {
+		return name + "@" + s.Domain
+	}
+	return ""
+}
+
+// Split делит результат выражения на имена по запятым, точкам с запятой и пробельным символам
+func Split(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
+
+// ParseLogins разбирает таблицу соответствий из строк вида «имя = логин»
+func ParseLogins(text string) (map[string]string, error) {
+	logins := make(map[string]string)
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, login, ok := strings.Cut(line, "=")
+		name, login = strings.TrimSpace(name), strings.TrimSpace(login)
+		if !ok || name == "" || login == "" {
+			return nil, fmt.Errorf("line %d: expected \"name = login\"", i+1)
+		}
+		logins[name] = login
+	}
+	return logins, nil
+}
+
+// FormatLogins записывает таблицу соответствий строками «имя = логин»
+func FormatLogins(logins map[string]string) string {
+	names := make([]string, 0, len(logins))
+	for name := range logins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + " = " + logins[name] + "\n")
+	}
+	return b.String()
+}
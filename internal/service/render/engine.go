@@ -0,0 +1,24 @@
+// Путь: internal/service/render/engine.go
+package render
+
+import (
+	"github.com/osteele/liquid"
+)
+
+// engine - общий движок: после регистрации фильтров конфигурация не меняется,
+// поэтому один экземпляр безопасно использовать из нескольких горутин
+var engine = NewEngine()
+
+// NewEngine создает Liquid-движок со стандартными и собственными фильтрами моста
+func NewEngine() *liquid.Engine {
+	e := liquid.NewEngine()
+	registerFilters(e)
+	return e
+}
+
+// Engine возвращает общий движок. Все места, где рендерятся или проверяются шаблоны
+// (вебхуки, ключи корреляции и дедупликации, правила, предпросмотр), используют его,
+// чтобы набор фильтров везде был одинаковым.
+func Engine() *liquid.Engine {
+	return engine
+}
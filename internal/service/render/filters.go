@@ -0,0 +1,347 @@
+// Путь: internal/service/render/filters.go
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // часовые пояса для format_time в образах без tzdata
+
+	"github.com/osteele/liquid"
+	"github.com/osteele/tuesday"
+)
+
+// registerFilters добавляет фильтры моста к стандартным фильтрам Liquid
+func registerFilters(e *liquid.Engine) {
+	e.RegisterFilter("duration", durationFilter)
+	e.RegisterFilter("parse_time", parseTimeFilter)
+	e.RegisterFilter("format_time", formatTimeFilter)
+	e.RegisterFilter("truncate_words", truncateWordsFilter)
+	e.RegisterFilter("escape_markdown", EscapeMarkdown)
+	e.RegisterFilter("json", jsonFilter)
+	e.RegisterFilter("default_if_empty", defaultIfEmptyFilter)
+	e.RegisterFilter("severity_emoji", severityEmojiFilter)
+	e.RegisterFilter("plural", pluralFilter)
+	e.RegisterFilter("jsonpath", jsonPathFilter)
+}
+
+// durationUnits - единицы для duration от крупной к мелкой
+var durationUnits = []struct {
+	seconds int64
+	suffix  string
+}{
+	{86400, "д"},
+	{3600, "ч"},
+	{60, "м"},
+	{1, "с"},
+}
+
+// missing сообщает, что поля нет или оно пустое. Фильтры форматирования возвращают для него пустую строку,
+// как стандартные фильтры Liquid: необязательное поле не должно ломать рендеринг всего сообщения.
+func missing(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
+
+// durationFilter переводит секунды (или строку вида 1h30m) в "1д 2ч 5м":
+// {{ 3600 | duration }} -> 1ч, {{ 5430 | duration: 1 }} -> 1ч (только старшая единица)
+func durationFilter(value interface{}, units func(int) int) (string, error) {
+	if missing(value) {
+		return "", nil
+	}
+
+	var total int64
+	if seconds, ok := toFloat(value); ok {
+		total = int64(math.Round(seconds))
+	} else if d, err := time.ParseDuration(strings.TrimSpace(fmt.Sprint(value))); err == nil {
+		total = int64(d.Round(time.Second) / time.Second)
+	} else {
+		return "", fmt.Errorf("not a duration: %v", value)
+	}
+
+	sign := ""
+	if total < 0 {
+		sign, total = "-", -total
+	}
+
+	limit := units(0)
+	var parts []string
+	for _, unit := range durationUnits {
+		if limit > 0 && len(parts) == limit {
+			break
+		}
+		if n := total / unit.seconds; n > 0 {
+			parts = append(parts, strconv.FormatInt(n, 10)+unit.suffix)
+			total -= n * unit.seconds
+		}
+	}
+	if len(parts) == 0 {
+		return "0с", nil
+	}
+	return sign + strings.Join(parts, " "), nil
+}
+
+// timeLayouts - форматы времени, которые встречаются в вебхуках (RFC 3339, Jira, GitLab)
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseTime разбирает время из строки или Unix-времени (секунды или миллисекунды):
+// {{ issue.fields.created | parse_time | date: "%d.%m.%Y" }}
+func ParseTime(value interface{}) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	if n, ok := toFloat(value); ok {
+		// Значения больше 10^12 - миллисекунды (Jira, Grafana)
+		if math.Abs(n) >= 1e12 {
+			return time.UnixMilli(int64(n)), nil
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+
+	s := strings.TrimSpace(fmt.Sprint(value))
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format: %q", s)
+}
+
+// parseTimeFilter - фильтр parse_time: пустое значение пропускается дальше как nil
+func parseTimeFilter(value interface{}) (interface{}, error) {
+	if missing(value) {
+		return nil, nil
+	}
+	return ParseTime(value)
+}
+
+// formatTimeFilter форматирует время в часовом поясе (strftime, как у date):
+// {{ startsAt | format_time: "%d.%m %H:%M", "Europe/Moscow" }}
+func formatTimeFilter(value interface{}, format func(string) string, zone func(string) string) (string, error) {
+	if missing(value) {
+		return "", nil
+	}
+
+	t, err := ParseTime(value)
+	if err != nil {
+		return "", err
+	}
+	if name := zone(""); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return "", fmt.Errorf("unknown time zone: %q", name)
+		}
+		t = t.In(loc)
+	}
+	return tuesday.Strftime(format("%d.%m.%Y %H:%M"), t)
+}
+
+// wordPattern - слово для truncate_words: ссылка [текст](url) считается одним словом вместе с пунктуацией вокруг
+var wordPattern = regexp.MustCompile(`(?:\[[^\]\n]*\]\([^)\s]*\)|\S)+`)
+
+// markdownPairs - парные маркеры разметки Яндекс Мессенджера, которые нужно закрыть после обрезки.
+// Блок кода проверяется первым, чтобы его ``` не считались тремя маркерами `.
+var markdownPairs = []string{"```", "`", "**", "~~", "__"}
+
+// truncateWordsFilter оставляет первые n слов и закрывает оборванную разметку:
+// {{ description | truncate_words: 30 }}
+func truncateWordsFilter(s string, length func(int) int, ellipsis func(string) string) string {
+	n := length(15)
+	words := wordPattern.FindAllStringIndex(s, -1)
+	if n <= 0 || len(words) <= n {
+		return s
+	}
+
+	cut := s[:words[n-1][1]]
+	rest := cut
+	var closing strings.Builder
+	for _, marker := range markdownPairs {
+		if strings.Count(rest, marker)%2 == 1 {
+			if marker == "```" {
+				closing.WriteString("\n")
+			}
+			closing.WriteString(marker)
+		}
+		rest = strings.ReplaceAll(rest, marker, "")
+	}
+	return cut + ellipsis("…") + closing.String()
+}
+
+// markdownEscaper экранирует символы разметки Яндекс Мессенджера
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+)
+
+// EscapeMarkdown экранирует разметку в пользовательском тексте (заголовки задач, комментарии):
+// {{ issue.fields.summary | escape_markdown }}
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// jsonFilter выводит значение как JSON; с аргументом - с отступом в указанное число пробелов:
+// {{ alert.labels | json: 2 }}
+func jsonFilter(value interface{}, indent func(int) int) (string, error) {
+	var data []byte
+	var err error
+	if n := indent(0); n > 0 {
+		data, err = json.MarshalIndent(value, "", strings.Repeat(" ", n))
+	} else {
+		data, err = json.Marshal(value)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// defaultIfEmptyFilter подставляет значение по умолчанию вместо nil, пустой строки, строки из пробелов
+// и пустого списка или объекта. В отличие от default, false и 0 не заменяются.
+func defaultIfEmptyFilter(value, defaultValue interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return defaultValue
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return defaultValue
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return defaultValue
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return defaultValue
+		}
+	}
+	return value
+}
+
+// severityEmoji - эмодзи для уровней важности Alertmanager, Grafana, Zabbix, Sentry и Jira
+var severityEmoji = map[string]string{
+	"critical":    "🔴",
+	"crit":        "🔴",
+	"fatal":       "🔴",
+	"emergency":   "🔴",
+	"disaster":    "🔴",
+	"blocker":     "🔴",
+	"highest":     "🔴",
+	"high":        "🟠",
+	"error":       "🟠",
+	"major":       "🟠",
+	"warning":     "🟡",
+	"warn":        "🟡",
+	"average":     "🟡",
+	"medium":      "🟡",
+	"info":        "🔵",
+	"information": "🔵",
+	"notice":      "🔵",
+	"low":         "🔵",
+	"minor":       "🔵",
+	"lowest":      "🔵",
+	"debug":       "⚪",
+	"none":        "⚪",
+	"ok":          "🟢",
+	"resolved":    "🟢",
+	"success":     "🟢",
+}
+
+// severityEmojiFilter переводит уровень важности в эмодзи:
+// {{ alert.labels.severity | severity_emoji }}, для неизвестных - {{ x | severity_emoji: "❔" }}
+func severityEmojiFilter(value interface{}, fallback func(string) string) string {
+	if value != nil {
+		if emoji, ok := severityEmoji[strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))]; ok {
+			return emoji
+		}
+	}
+	return fallback("⚪")
+}
+
+// pluralFilter выбирает форму слова по правилам русского языка:
+// {{ count }} {{ count | plural: "задача", "задачи", "задач" }}
+func pluralFilter(value interface{}, one, few, many string) (string, error) {
+	if missing(value) {
+		return "", nil
+	}
+
+	n, ok := toFloat(value)
+	if !ok {
+		return "", fmt.Errorf("not a number: %v", value)
+	}
+	if n != math.Trunc(n) {
+		return few, nil
+	}
+
+	i := int64(math.Abs(n))
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return one, nil
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return few, nil
+	default:
+		return many, nil
+	}
+}
+
+// jsonPathFilter возвращает значение по пути внутри объекта или JSON-строки:
+// {{ alerts | jsonpath: "$[0].labels.severity" }}, {{ _raw | jsonpath: "issue.key" }}
+func jsonPathFilter(value interface{}, path string) interface{} {
+	if s, ok := value.(string); ok {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err == nil {
+			value = decoded
+		}
+	}
+	result, _ := Lookup(value, path)
+	return result
+}
+
+// toFloat приводит число или числовую строку к float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
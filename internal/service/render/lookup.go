@@ -0,0 +1,43 @@
+// Путь: internal/service/render/lookup.go
+package render
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// indexPattern - индексы и ключи в квадратных скобках: a[0], a["key"]
+var indexPattern = regexp.MustCompile(`\[\s*(?:"([^"]*)"|'([^']*)'|(\d+))\s*\]`)
+
+// Lookup возвращает значение по пути вида a.b.0.c (индексы массивов - числами).
+// Допускаются префикс $. и скобки: $.alerts[0].labels["severity"].
+func Lookup(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = indexPattern.ReplaceAllString(path, ".$1$2$3")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return data, true
+	}
+
+	current := data
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
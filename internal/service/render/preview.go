@@ -0,0 +1,108 @@
+// Путь: internal/service/render/preview.go
+package render
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/osteele/liquid"
+)
+
+// Error - ошибка шаблона с позицией для редактора. Line и Column считаются с 1, 0 - позиция неизвестна.
+type Error struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// Error реализует интерфейс error
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return "line " + strconv.Itoa(e.Line) + ", column " + strconv.Itoa(e.Column) + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Preview разбирает и рендерит шаблон общим движком. Ошибка Liquid возвращается с позицией в тексте шаблона.
+func Preview(text string, data map[string]interface{}) (string, *Error) {
+	template, err := Parse(text)
+	if err != nil {
+		return "", Describe(err, text)
+	}
+	out, err := template.RenderString(data)
+	if err != nil {
+		return "", Describe(err, text)
+	}
+	return out, nil
+}
+
+// Parse разбирает шаблон общим движком. В отличие от ParseString строки в ошибках нумеруются с 1,
+// а ошибка в первой строке тоже содержит номер строки.
+func Parse(text string) (*liquid.Template, liquid.SourceError) {
+	return Engine().ParseTemplateLocation([]byte(text), "", 1)
+}
+
+// errorPrefix - начало сообщения об ошибке Liquid: "Liquid error (line 3): "
+var errorPrefix = regexp.MustCompile(`^Liquid error(?: \(line (\d+)\))?: `)
+
+// Describe переводит ошибку Liquid в Error. Liquid сообщает только строку и фрагмент шаблона
+// ("... in {{ x | y }}"), столбец находится поиском этого фрагмента в строке.
+func Describe(err error, text string) *Error {
+	result := &Error{Message: err.Error()}
+
+	var sourceErr liquid.SourceError
+	if errors.As(err, &sourceErr) {
+		result.Line = sourceErr.LineNumber()
+	}
+
+	message := result.Message
+	if m := errorPrefix.FindStringSubmatch(message); m != nil {
+		message = message[len(m[0]):]
+		if result.Line == 0 && m[1] != "" {
+			result.Line, _ = strconv.Atoi(m[1])
+		}
+	}
+	if result.Line <= 0 {
+		result.Message = message
+		return result
+	}
+
+	// Отрезаем фрагмент " in <исходник тега>" и ищем его начиная со строки ошибки
+	lineStart := lineOffset(text, result.Line)
+	for i := strings.Index(message, " in "); i >= 0; {
+		fragment := message[i+len(" in "):]
+		if pos := strings.Index(text[lineStart:], fragment); fragment != "" && pos >= 0 {
+			if !strings.Contains(text[lineStart:lineStart+pos], "\n") {
+				result.Column = utf8.RuneCountInString(text[lineStart:lineStart+pos]) + 1
+				message = message[:i]
+			}
+			break
+		}
+		next := strings.Index(message[i+1:], " in ")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if result.Column == 0 {
+		result.Column = 1
+	}
+	result.Message = message
+	return result
+}
+
+// lineOffset возвращает смещение начала строки line (с 1) в тексте
+func lineOffset(text string, line int) int {
+	offset := 0
+	for n := 1; n < line; n++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	return offset
+}
@@ -0,0 +1,152 @@
+// Путь: internal/service/routing/routes.go
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/osteele/liquid"
+
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/render"
+)
+
+// SettingsKey - ключ маршрутов в CustomSettings экземпляра
+const SettingsKey = "routes"
+
+// Route - маршрут: условие, получатель и необязательный собственный шаблон.
+// Маршруты проверяются по порядку, как дерево маршрутизации Alertmanager:
+// совпавший маршрут без continue завершает поиск.
+type Route struct {
+	Name string `json:"name,omitempty"`
+	filter.Condition
+	ChatID   string `json:"chat_id,omitempty"`
+	Login    string `json:"login,omitempty"`    // личное сообщение пользователю Яндекс 360
+	Template string `json:"template,omitempty"` // Liquid-шаблон вместо шаблона экземпляра
+	Continue bool   `json:"continue,omitempty"` // проверять следующие маршруты после совпадения
+}
+
+// Settings - маршруты экземпляра. Если ни один маршрут не совпал, сообщение уходит в чат экземпляра.
+type Settings struct {
+	Routes []Route `json:"routes"`
+}
+
+// Target - получатель сообщения
+type Target struct {
+	Route    string // имя маршрута, пустое для чата экземпляра
+	ChatID   string
+	Login    string
+	Template string // пустой - шаблон экземпляра
+}
+
+// FromCustomSettings извлекает маршруты из CustomSettings экземпляра.
+// Возвращает nil, если маршрутов нет.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid routes: %w", err)
+	}
+	if len(s.Routes) == 0 {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет маршруты в CustomSettings. Пустой список удаляет настройки.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || len(s.Routes) == 0 {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// Validate проверяет маршруты
+func (s *Settings) Validate() error {
+	_, err := s.Compile(render.Engine())
+	return err
+}
+
+// Router - скомпилированные маршруты
+type Router struct {
+	routes   []compiledRoute
+	fallback Target
+}
+
+type compiledRoute struct {
+	Route
+	matcher *filter.Matcher // nil - маршрут совпадает всегда
+}
+
+// Compile проверяет и компилирует маршруты
+func (s *Settings) Compile(engine *liquid.Engine) (*Router, error) {
+	router := &Router{}
+	for i, route := range s.Routes {
+		n := i + 1
+		route.ChatID = strings.TrimSpace(route.ChatID)
+		route.Login = strings.TrimSpace(route.Login)
+
+		if (route.ChatID == "") == (route.Login == "") {
+			return nil, fmt.Errorf("route %d: exactly one of chat_id and login is required", n)
+		}
+		if route.Template != "" {
+			if _, err := engine.ParseString(route.Template); err != nil {
+				return nil, fmt.Errorf("route %d: invalid template: %w", n, err)
+			}
+		}
+
+		compiled := compiledRoute{Route: route}
+		if route.Match != "" {
+			matcher, err := route.Condition.Compile(engine)
+			if err != nil {
+				return nil, fmt.Errorf("route %d: %w", n, err)
+			}
+			compiled.matcher = matcher
+		}
+		router.routes = append(router.routes, compiled)
+	}
+	return router, nil
+}
+
+// Resolve возвращает получателей события. defaultChatID - чат экземпляра,
+// в который уходит сообщение, если ни один маршрут не совпал.
+func (r *Router) Resolve(in filter.Input, defaultChatID string) ([]Target, error) {
+	var targets []Target
+	for i, route := range r.routes {
+		if route.matcher != nil {
+			matched, err := route.matcher.Matches(in)
+			if err != nil {
+				return nil, fmt.Errorf("route %d: %w", i+1, err)
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		targets = append(targets, Target{
+			Route:    route.Name,
+			ChatID:   route.ChatID,
+			Login:    route.Login,
+			Template: route.Template,
+		})
+		if !route.Continue {
+			break
+		}
+	}
+
+	if len(targets) == 0 {
+		targets = append(targets, Target{ChatID: defaultChatID})
+	}
+	return targets, nil
+}
@@ -0,0 +1,103 @@
+// Путь: internal/service/samples/samples.go
+package samples
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/payload"
+)
+
+// Ошибки создания примеров
+var (
+	ErrInvalidName   = errors.New("sample name must be 1-100 characters: letters, digits, '_', '-' or '.'")
+	ErrEmptyPayload  = errors.New("sample payload is empty")
+	ErrNoLastWebhook = errors.New("no webhooks received yet")
+)
+
+// namePattern - имя примера используется в тестах шаблонов и в URL, поэтому без пробелов
+var namePattern = regexp.MustCompile(`^[\p{L}\p{N}_.-]{1,100}$`)
+
+// ValidateName проверяет имя примера (issue_created, worklog_deleted, ...)
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// New создает пример из загруженного тела. contentType сохраняется в заголовках,
+// чтобы тело разбиралось так же, как вебхук с этим Content-Type.
+func New(templateID, name, description, contentType string, body []byte) (*domain.TemplateSample, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, ErrEmptyPayload
+	}
+
+	sample := &domain.TemplateSample{
+		TemplateID:  templateID,
+		Name:        name,
+		Description: description,
+		Payload:     payload.StoredBody(body),
+	}
+	if contentType != "" {
+		sample.Headers, _ = json.Marshal(http.Header{"Content-Type": {contentType}})
+	}
+	return sample, nil
+}
+
+// FromLastWebhook создает пример шаблона экземпляра из его последнего вебхука вместе с заголовками.
+// Заголовки с секретами не копируются: пример публичного шаблона видят все пользователи.
+func FromLastWebhook(instance *domain.IntegrationInstance, name, description string) (*domain.TemplateSample, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if instance.LastWebhookAt == nil || len(instance.LastWebhookBody) == 0 {
+		return nil, ErrNoLastWebhook
+	}
+
+	return &domain.TemplateSample{
+		TemplateID:  instance.TemplateID,
+		Name:        name,
+		Description: description,
+		Headers:     publicHeaders(instance.LastWebhookHeaders),
+		Payload:     instance.LastWebhookBody,
+	}, nil
+}
+
+// publicHeaders убирает заголовки, которые могут содержать секреты
+func publicHeaders(raw json.RawMessage) json.RawMessage {
+	header := http.Header{}
+	if len(raw) == 0 || json.Unmarshal(raw, &header) != nil {
+		return nil
+	}
+
+	for name := range header {
+		if payload.IsSecretHeader(name) {
+			delete(header, name)
+		}
+	}
+
+	cleaned, _ := json.Marshal(header)
+	return cleaned
+}
+
+// Find возвращает пример по имени или ID, а для пустого имени - первый пример шаблона
+func Find(samples []*domain.TemplateSample, name string) *domain.TemplateSample {
+	for _, sample := range samples {
+		if name == "" || sample.Name == name || sample.ID == name {
+			return sample
+		}
+	}
+	return nil
+}
+
+// Body возвращает тело и заголовки примера для разбора, как у вебхука
+func Body(sample *domain.TemplateSample) ([]byte, http.Header) {
+	return payload.Stored(sample.Headers, sample.Payload)
+}
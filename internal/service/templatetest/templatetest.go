@@ -0,0 +1,213 @@
+// Путь: internal/service/templatetest/templatetest.go
+
+// Package templatetest выполняет тесты шаблонов (domain.TemplateTest) без базы данных и сети:
+// данные готовятся так же, как для предпросмотра, а текст сообщения сравнивается с ожидаемым.
+// Пакет можно вызывать из go test, чтобы встроенные шаблоны проверялись в CI:
+//
+//	report := templatetest.Run(template)
+//	if !report.OK() {
+//		t.Fatal(report.Summary())
+//	}
+package templatetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/preview"
+	"yandex-messenger-bridge/internal/service/samples"
+)
+
+// Result - результат одного теста
+type Result struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Output string `json:"output"`          // текст сообщения после front matter
+	Blank  bool   `json:"blank,omitempty"` // пустой результат: сообщение не будет отправлено
+	Error  string `json:"error,omitempty"` // ошибка данных или рендеринга либо описание несовпадения
+}
+
+// Report - результаты всех тестов шаблона
+type Report struct {
+	Template string   `json:"template"`
+	Passed   int      `json:"passed"`
+	Failed   int      `json:"failed"`
+	Results  []Result `json:"results"`
+}
+
+// OK сообщает, что все тесты прошли
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// Summary возвращает краткий итог для логов и сообщений об ошибке
+func (r *Report) Summary() string {
+	if r.OK() {
+		return fmt.Sprintf("%s: %d tests passed", r.Template, r.Passed)
+	}
+
+	failed := make([]string, 0, r.Failed)
+	for _, result := range r.Results {
+		if !result.Passed {
+			failed = append(failed, result.Name+" ("+result.Error+")")
+		}
+	}
+	return fmt.Sprintf("%s: %d of %d tests failed: %s", r.Template, r.Failed, len(r.Results), strings.Join(failed, "; "))
+}
+
+// Parse разбирает тесты из JSON-массива (поле редактора шаблона); пустой текст - нет тестов
+func Parse(text string) ([]domain.TemplateTest, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+
+	var tests []domain.TemplateTest
+	if err := json.Unmarshal([]byte(text), &tests); err != nil {
+		return nil, fmt.Errorf("invalid tests JSON: %w", err)
+	}
+	return tests, Validate(tests)
+}
+
+// Validate проверяет имена, способ сравнения, регулярные выражения и наличие входных данных
+func Validate(tests []domain.TemplateTest) error {
+	names := make(map[string]bool, len(tests))
+	for i, test := range tests {
+		if test.Name == "" {
+			return fmt.Errorf("test #%d: name is required", i+1)
+		}
+		if names[test.Name] {
+			return fmt.Errorf("test %q: duplicate name", test.Name)
+		}
+		names[test.Name] = true
+
+		if test.Sample == "" && len(test.Payload) == 0 {
+			return fmt.Errorf("test %q: sample or payload is required", test.Name)
+		}
+		switch test.Match {
+		case "", domain.TemplateTestExact, domain.TemplateTestContains:
+		case domain.TemplateTestRegex:
+			if _, err := regexp.Compile(test.Expected); err != nil {
+				return fmt.Errorf("test %q: invalid regex: %w", test.Name, err)
+			}
+		default:
+			return fmt.Errorf("test %q: unknown match %q", test.Name, test.Match)
+		}
+	}
+	return nil
+}
+
+// Run выполняет все тесты шаблона. Тесты с примером данных берут его из template.Samples.
+func Run(template *domain.Template) *Report {
+	report := &Report{Template: template.Name, Results: make([]Result, 0, len(template.Tests))}
+	for _, test := range template.Tests {
+		result := RunTest(template, test)
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// RunTest рендерит шаблон на данных теста и сравнивает текст сообщения с ожидаемым
+func RunTest(template *domain.Template, test domain.TemplateTest) Result {
+	result := Result{Name: test.Name}
+
+	body, header, err := input(template, test)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	data, err := preview.Prepare(body, header, time.Now(), nil, template)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	rendered := preview.Run(template.TemplateText, data)
+	if rendered.Error != nil {
+		result.Error = rendered.Error.Message
+		if rendered.Error.Line > 0 {
+			result.Error = fmt.Sprintf("line %d, column %d: %s", rendered.Error.Line, rendered.Error.Column, rendered.Error.Message)
+		}
+		return result
+	}
+
+	result.Output, result.Blank = rendered.Text, rendered.Blank
+	if err := match(test, rendered.Text); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Passed = true
+	return result
+}
+
+// input возвращает тело и заголовки теста: из примера шаблона или из самого теста
+func input(template *domain.Template, test domain.TemplateTest) ([]byte, http.Header, error) {
+	if test.Sample != "" {
+		sample := samples.Find(template.Samples, test.Sample)
+		if sample == nil {
+			return nil, nil, fmt.Errorf("sample %q not found", test.Sample)
+		}
+		body, header := samples.Body(sample)
+		return body, header, nil
+	}
+
+	header := http.Header{}
+	for name, value := range test.Headers {
+		header.Set(name, value)
+	}
+
+	// Строка - тело как есть (форма, XML, текст), остальное - JSON
+	var text string
+	if json.Unmarshal(test.Payload, &text) == nil {
+		return []byte(text), header, nil
+	}
+	return []byte(test.Payload), header, nil
+}
+
+// match сравнивает текст сообщения с ожидаемым
+func match(test domain.TemplateTest, output string) error {
+	switch test.Match {
+	case domain.TemplateTestContains:
+		if !strings.Contains(output, test.Expected) {
+			return fmt.Errorf("output does not contain %q", test.Expected)
+		}
+	case domain.TemplateTestRegex:
+		re, err := regexp.Compile(test.Expected)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		if !re.MatchString(output) {
+			return fmt.Errorf("output does not match /%s/", test.Expected)
+		}
+	default:
+		if strings.TrimSpace(output) != strings.TrimSpace(test.Expected) {
+			return fmt.Errorf("output differs from expected")
+		}
+	}
+	return nil
+}
+
+// ErrFailed - публичный шаблон не прошёл свои тесты
+var ErrFailed = errors.New("template tests failed")
+
+// Check выполняет тесты перед сохранением шаблона. Публичный шаблон, не прошедший тесты,
+// сохранять нельзя (ErrFailed): им пользуются все. Личный шаблон сохраняется, отчёт возвращается для предупреждения.
+func Check(template *domain.Template) (*Report, error) {
+	report := Run(template)
+	if !report.OK() && template.IsPublic {
+		return report, ErrFailed
+	}
+	return report, nil
+}
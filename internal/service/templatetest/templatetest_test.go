@@ -0,0 +1,204 @@
+package templatetest
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// testTemplate - шаблон GitLab push с примером данных и тестами
+func testTemplate(tests ...domain.TemplateTest) *domain.Template {
+	return &domain.Template{
+		Name:         "push",
+		TemplateText: "⬆️ **{{ user_name }}** → `{{ ref | remove_first: \"refs/heads/\" }}`\n{{ total_commits_count }} commits",
+		Samples: []*domain.TemplateSample{{
+			Name:    "push",
+			Headers: json.RawMessage(`{"Content-Type":["application/json"]}`),
+			Payload: json.RawMessage(`{"user_name":"Иван","ref":"refs/heads/main","total_commits_count":3}`),
+		}},
+		Tests: tests,
+	}
+}
+
+func TestRunTestMatches(t *testing.T) {
+	cases := []struct {
+		name   string
+		test   domain.TemplateTest
+		passed bool
+	}{
+		{
+			name:   "exact",
+			test:   domain.TemplateTest{Name: "t", Sample: "push", Expected: "⬆️ **Иван** → `main`\n3 commits\n"},
+			passed: true,
+		},
+		{
+			name:   "exact mismatch",
+			test:   domain.TemplateTest{Name: "t", Sample: "push", Expected: "⬆️ **Иван** → `main`"},
+			passed: false,
+		},
+		{
+			name:   "contains",
+			test:   domain.TemplateTest{Name: "t", Sample: "push", Match: domain.TemplateTestContains, Expected: "`main`"},
+			passed: true,
+		},
+		{
+			name:   "contains mismatch",
+			test:   domain.TemplateTest{Name: "t", Sample: "push", Match: domain.TemplateTestContains, Expected: "`develop`"},
+			passed: false,
+		},
+		{
+			name:   "regex",
+			test:   domain.TemplateTest{Name: "t", Sample: "push", Match: domain.TemplateTestRegex, Expected: `(?m)^\d+ commits$`},
+			passed: true,
+		},
+		{
+			name:   "regex mismatch",
+			test:   domain.TemplateTest{Name: "t", Sample: "push", Match: domain.TemplateTestRegex, Expected: `^\d+ commits$`},
+			passed: false,
+		},
+		{
+			name: "inline payload and headers",
+			test: domain.TemplateTest{
+				Name:     "t",
+				Payload:  json.RawMessage(`{"user_name":"Мария","ref":"refs/heads/dev","total_commits_count":1}`),
+				Headers:  map[string]string{"Content-Type": "application/json"},
+				Match:    domain.TemplateTestContains,
+				Expected: "**Мария** → `dev`",
+			},
+			passed: true,
+		},
+		{
+			name:   "unknown sample",
+			test:   domain.TemplateTest{Name: "t", Sample: "missing", Expected: ""},
+			passed: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := RunTest(testTemplate(), tc.test)
+			if result.Passed != tc.passed {
+				t.Fatalf("passed = %v, want %v (output %q, error %q)", result.Passed, tc.passed, result.Output, result.Error)
+			}
+			if !result.Passed && result.Error == "" {
+				t.Fatal("failed result has no error")
+			}
+		})
+	}
+}
+
+func TestRunTestRenderError(t *testing.T) {
+	template := testTemplate()
+	template.TemplateText = "{% if %}"
+
+	result := RunTest(template, domain.TemplateTest{Name: "t", Sample: "push", Expected: ""})
+	if result.Passed || result.Error == "" {
+		t.Fatalf("broken template passed: %+v", result)
+	}
+}
+
+func TestRunTestBlankOutput(t *testing.T) {
+	template := testTemplate()
+	template.TemplateText = "{% if object_kind == \"issue\" %}issue{% endif %}"
+
+	result := RunTest(template, domain.TemplateTest{Name: "t", Sample: "push", Expected: ""})
+	if !result.Passed || !result.Blank {
+		t.Fatalf("blank output: %+v", result)
+	}
+}
+
+func TestRun(t *testing.T) {
+	report := Run(testTemplate(
+		domain.TemplateTest{Name: "ok", Sample: "push", Match: domain.TemplateTestContains, Expected: "Иван"},
+		domain.TemplateTest{Name: "bad", Sample: "push", Match: domain.TemplateTestContains, Expected: "Мария"},
+	))
+
+	if report.Passed != 1 || report.Failed != 1 || len(report.Results) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.OK() {
+		t.Fatal("report with a failed test is OK")
+	}
+	if !strings.Contains(report.Summary(), "bad") {
+		t.Fatalf("summary does not name the failed test: %s", report.Summary())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	failing := domain.TemplateTest{Name: "bad", Sample: "push", Expected: "nope"}
+
+	public := testTemplate(failing)
+	public.IsPublic = true
+	if _, err := Check(public); !errors.Is(err, ErrFailed) {
+		t.Fatalf("public template with failing tests: err = %v, want ErrFailed", err)
+	}
+
+	private := testTemplate(failing)
+	report, err := Check(private)
+	if err != nil {
+		t.Fatalf("private template with failing tests: err = %v", err)
+	}
+	if report.OK() {
+		t.Fatal("private template report is OK")
+	}
+
+	passing := testTemplate(domain.TemplateTest{Name: "ok", Sample: "push", Match: domain.TemplateTestContains, Expected: "main"})
+	passing.IsPublic = true
+	if _, err := Check(passing); err != nil {
+		t.Fatalf("passing public template: err = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		tests []domain.TemplateTest
+		err   string
+	}{
+		{name: "valid", tests: []domain.TemplateTest{
+			{Name: "a", Sample: "push"},
+			{Name: "b", Payload: json.RawMessage(`{}`), Match: domain.TemplateTestRegex, Expected: `^ok$`},
+			{Name: "c", Sample: "push", Match: domain.TemplateTestContains},
+		}},
+		{name: "no name", tests: []domain.TemplateTest{{Sample: "push"}}, err: "name is required"},
+		{name: "duplicate", tests: []domain.TemplateTest{{Name: "a", Sample: "push"}, {Name: "a", Sample: "push"}}, err: "duplicate name"},
+		{name: "no input", tests: []domain.TemplateTest{{Name: "a"}}, err: "sample or payload is required"},
+		{name: "invalid regex", tests: []domain.TemplateTest{{Name: "a", Sample: "push", Match: domain.TemplateTestRegex, Expected: `(`}}, err: "invalid regex"},
+		{name: "unknown match", tests: []domain.TemplateTest{{Name: "a", Sample: "push", Match: "glob"}}, err: "unknown match"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.tests)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("err = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests, err := Parse(`[{"name":"a","sample":"push","match":"contains","expected":"main"}]`)
+	if err != nil || len(tests) != 1 || tests[0].Match != domain.TemplateTestContains {
+		t.Fatalf("tests = %+v, err = %v", tests, err)
+	}
+
+	if tests, err := Parse("  "); err != nil || tests != nil {
+		t.Fatalf("empty text: tests = %+v, err = %v", tests, err)
+	}
+	if _, err := Parse(`[{"name":"a","sample":"push","match":"regex","expected":"["}]`); err == nil {
+		t.Fatal("invalid regex accepted")
+	}
+	if _, err := Parse(`{`); err == nil {
+		t.Fatal("invalid JSON accepted")
+	}
+}
@@ -0,0 +1,186 @@
+// Путь: internal/service/verification/settings.go
+package verification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"yandex-messenger-bridge/internal/service/encryption"
+)
+
+// SettingsKey - ключ настроек проверки в CustomSettings экземпляра
+const SettingsKey = "verification"
+
+// Режимы проверки входящих вебхуков
+const (
+	ModeNone        = ""
+	ModeGitLabToken = "gitlab_token" // заголовок X-Gitlab-Token
+	ModeGitHubHMAC  = "github_hmac"  // заголовок X-Hub-Signature-256
+	ModeHMAC        = "hmac"         // произвольный заголовок и алгоритм HMAC
+	ModeBearer      = "bearer"       // Authorization: Bearer <token>
+	ModeBasic       = "basic"        // HTTP Basic auth
+	ModeIPAllowlist = "ip_allowlist" // список разрешённых подсетей
+)
+
+// Алгоритмы HMAC
+const (
+	AlgorithmSHA1   = "sha1"
+	AlgorithmSHA256 = "sha256"
+	AlgorithmSHA512 = "sha512"
+)
+
+// DefaultHMACHeader - заголовок подписи по умолчанию для режима hmac
+const DefaultHMACHeader = "X-Signature"
+
+// Settings - настройки проверки вебхуков экземпляра
+type Settings struct {
+	Mode       string   `json:"mode"`
+	Secret     string   `json:"secret,omitempty"` // хранится зашифрованным
+	Username   string   `json:"username,omitempty"`
+	Header     string   `json:"header,omitempty"`
+	Algorithm  string   `json:"algorithm,omitempty"`
+	AllowedIPs []string `json:"allowed_ips,omitempty"` // подсети в нотации CIDR или отдельные адреса
+}
+
+// FromCustomSettings извлекает настройки проверки из CustomSettings экземпляра.
+// Возвращает nil, если проверка не настроена.
+func FromCustomSettings(custom map[string]interface{}) (*Settings, error) {
+	raw, ok := custom[SettingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid verification settings: %w", err)
+	}
+	if s.Mode == ModeNone {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// Store сохраняет настройки в CustomSettings. nil или пустой режим отключают проверку.
+func (s *Settings) Store(custom map[string]interface{}) {
+	if s == nil || s.Mode == ModeNone {
+		delete(custom, SettingsKey)
+		return
+	}
+	custom[SettingsKey] = s
+}
+
+// NeedsSecret сообщает, требуется ли для режима секрет
+func (s *Settings) NeedsSecret() bool {
+	switch s.Mode {
+	case ModeGitLabToken, ModeGitHubHMAC, ModeHMAC, ModeBearer, ModeBasic:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetSecret шифрует новый секрет. Пустой secret сохраняет секрет из current (если он есть).
+func (s *Settings) SetSecret(encryptor *encryption.Encryptor, secret string, current *Settings) error {
+	if !s.NeedsSecret() {
+		s.Secret = ""
+		return nil
+	}
+
+	if secret == "" {
+		if current != nil {
+			s.Secret = current.Secret
+		}
+		return nil
+	}
+
+	encrypted, err := encryptor.Encrypt(secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	s.Secret = encrypted
+	return nil
+}
+
+// Validate проверяет корректность настроек
+func (s *Settings) Validate() error {
+	switch s.Mode {
+	case ModeGitLabToken, ModeGitHubHMAC, ModeBearer:
+	case ModeHMAC:
+		if _, err := hashFunc(s.algorithm()); err != nil {
+			return err
+		}
+	case ModeBasic:
+		if s.Username == "" {
+			return fmt.Errorf("username is required for basic auth")
+		}
+	case ModeIPAllowlist:
+		if len(s.AllowedIPs) == 0 {
+			return fmt.Errorf("at least one allowed network is required")
+		}
+		for _, cidr := range s.AllowedIPs {
+			if _, err := parseNetwork(cidr); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown verification mode %q", s.Mode)
+	}
+
+	if s.NeedsSecret() && s.Secret == "" {
+		return fmt.Errorf("secret is required for mode %q", s.Mode)
+	}
+	return nil
+}
+
+// header возвращает имя заголовка подписи для режима hmac
+func (s *Settings) header() string {
+	if s.Header == "" {
+		return DefaultHMACHeader
+	}
+	return s.Header
+}
+
+// algorithm возвращает алгоритм HMAC, по умолчанию sha256
+func (s *Settings) algorithm() string {
+	if s.Algorithm == "" {
+		return AlgorithmSHA256
+	}
+	return strings.ToLower(s.Algorithm)
+}
+
+// ParseAllowedIPs разбирает список подсетей, разделённых переводами строк, запятыми или пробелами
+func ParseAllowedIPs(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	return fields
+}
+
+// parseNetwork разбирает подсеть CIDR; отдельный адрес считается подсетью /32 или /128
+func parseNetwork(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", value)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q", value)
+	}
+	return network, nil
+}
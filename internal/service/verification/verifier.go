@@ -0,0 +1,193 @@
+// Путь: internal/service/verification/verifier.go
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net"
+	"net/http"
+	"strings"
+
+	"yandex-messenger-bridge/internal/service/encryption"
+)
+
+// ErrRejected - запрос не прошёл проверку (ответ 401)
+var ErrRejected = errors.New("webhook verification failed")
+
+// Verifier проверяет подлинность входящих вебхуков
+type Verifier struct {
+	encryptor         *encryption.Encryptor
+	trustProxyHeaders bool
+}
+
+// NewVerifier создает проверяющего. trustProxyHeaders разрешает брать адрес клиента
+// из X-Real-IP / X-Forwarded-For, выставленных ingress-контроллером.
+func NewVerifier(encryptor *encryption.Encryptor, trustProxyHeaders bool) *Verifier {
+	return &Verifier{
+		encryptor:         encryptor,
+		trustProxyHeaders: trustProxyHeaders,
+	}
+}
+
+// Verify проверяет запрос согласно настройкам экземпляра.
+// Ошибки отказа оборачивают ErrRejected, остальные ошибки - внутренние.
+func (v *Verifier) Verify(r *http.Request, body []byte, s *Settings) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Mode == ModeIPAllowlist {
+		return v.verifyIP(r, s)
+	}
+
+	secret, err := v.encryptor.Decrypt(s.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt verification secret: %w", err)
+	}
+
+	switch s.Mode {
+	case ModeGitLabToken:
+		token := r.Header.Get("X-Gitlab-Token")
+		if token == "" {
+			return reject("missing X-Gitlab-Token header")
+		}
+		if !equal(token, secret) {
+			return reject("invalid X-Gitlab-Token")
+		}
+		return nil
+
+	case ModeGitHubHMAC:
+		return verifyHMAC(r.Header.Get("X-Hub-Signature-256"), "X-Hub-Signature-256", AlgorithmSHA256, secret, body)
+
+	case ModeHMAC:
+		return verifyHMAC(r.Header.Get(s.header()), s.header(), s.algorithm(), secret, body)
+
+	case ModeBearer:
+		auth := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok {
+			return reject("missing bearer token")
+		}
+		if !equal(strings.TrimSpace(token), secret) {
+			return reject("invalid bearer token")
+		}
+		return nil
+
+	case ModeBasic:
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			return reject("missing basic auth credentials")
+		}
+		// Сравниваем обе части, чтобы время ответа не зависело от того, какая неверна
+		userOK := equal(username, s.Username)
+		passOK := equal(password, secret)
+		if !userOK || !passOK {
+			return reject("invalid basic auth credentials")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown verification mode %q", s.Mode)
+	}
+}
+
+// verifyIP проверяет адрес клиента по списку разрешённых подсетей
+func (v *Verifier) verifyIP(r *http.Request, s *Settings) error {
+	ip := net.ParseIP(v.clientIP(r))
+	if ip == nil {
+		return reject("unable to determine client IP")
+	}
+
+	for _, cidr := range s.AllowedIPs {
+		network, err := parseNetwork(cidr)
+		if err != nil {
+			continue
+		}
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	return reject(fmt.Sprintf("client IP %s is not allowed", ip))
+}
+
+// clientIP возвращает адрес клиента
+func (v *Verifier) clientIP(r *http.Request) string {
+	if v.trustProxyHeaders {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+		// Последний адрес добавлен ближайшим прокси, предыдущие может подделать клиент
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			parts := strings.Split(xff, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// verifyHMAC сравнивает подпись из заголовка с HMAC тела запроса.
+// Подпись принимается в hex или base64, с необязательным префиксом "<алгоритм>=".
+func verifyHMAC(signature, header, algorithm, secret string, body []byte) error {
+	if signature == "" {
+		return reject("missing " + header + " header")
+	}
+
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	signature = strings.TrimSpace(signature)
+	if prefix, value, ok := strings.Cut(signature, "="); ok && strings.EqualFold(prefix, algorithm) {
+		signature = value
+	}
+
+	if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+		return nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+		return nil
+	}
+	return reject("invalid " + header + " signature")
+}
+
+// hashFunc возвращает хеш-функцию по названию алгоритма
+func hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported HMAC algorithm %q", algorithm)
+	}
+}
+
+// equal сравнивает строки за постоянное время
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// reject возвращает ошибку отказа с причиной
+func reject(reason string) error {
+	return fmt.Errorf("%w: %s", ErrRejected, reason)
+}
@@ -4,8 +4,10 @@ package webhook
 import (
     "context"
     "encoding/json"
+    "errors"
     "io"
     "net/http"
+    "sort"
     "strings"
     "time"
 
@@ -13,8 +15,21 @@ import (
     "github.com/rs/zerolog/log"
 
     "bytes"
+    "yandex-messenger-bridge/internal/domain"
     "yandex-messenger-bridge/internal/repository/interface"
+    "yandex-messenger-bridge/internal/service/alertmanager"
+    "yandex-messenger-bridge/internal/service/cache"
+    "yandex-messenger-bridge/internal/service/correlation"
+    "yandex-messenger-bridge/internal/service/dedup"
+    "yandex-messenger-bridge/internal/service/delivery"
     "yandex-messenger-bridge/internal/service/encryption"
+    "yandex-messenger-bridge/internal/service/filter"
+    "yandex-messenger-bridge/internal/service/message"
+    "yandex-messenger-bridge/internal/service/payload"
+    "yandex-messenger-bridge/internal/service/recipients"
+    "yandex-messenger-bridge/internal/service/render"
+    "yandex-messenger-bridge/internal/service/routing"
+    "yandex-messenger-bridge/internal/service/verification"
     "yandex-messenger-bridge/internal/yandex"
 )
 
@@ -23,7 +38,9 @@ type Config struct {
     GitLabTimeout       time.Duration
     AlertmanagerTimeout time.Duration
     JiraTimeout         time.Duration
-    MaxRetries          int
+    MaxRetries          int      // количество повторных попыток отправки после первой неудачной
+    TrustProxyHeaders   bool     // брать адрес клиента из X-Real-IP / X-Forwarded-For
+    AttachmentAllowlist []string // внутренние хосты и подсети, с которых можно скачивать вложения
 }
 
 // Handler - обработчик вебхуков
@@ -31,6 +48,9 @@ type Handler struct {
     repo      _interface.IntegrationRepository
     yandex    *yandex.Client
     encryptor *encryption.Encryptor
+    verifier  *verification.Verifier
+    cache     *cache.Cache
+    sender    *delivery.Sender // синхронная отправка при проверке на последнем вебхуке
     config    Config
 }
 
@@ -39,12 +59,16 @@ func NewHandler(
     repo _interface.IntegrationRepository,
     yandex *yandex.Client,
     encryptor *encryption.Encryptor,
+    cache *cache.Cache,
     config Config,
 ) *Handler {
     return &Handler{
         repo:      repo,
         yandex:    yandex,
         encryptor: encryptor,
+        verifier:  verification.NewVerifier(encryptor, config.TrustProxyHeaders),
+        cache:     cache,
+        sender:    delivery.NewSender(repo, encryptor, 30*time.Second, config.AttachmentAllowlist),
         config:    config,
     }
 }
@@ -102,19 +126,45 @@ func (h *Handler) HandleInstanceWebhook(w http.ResponseWriter, r *http.Request)
         Int("body_size", len(body)).
         Msg("📦 Webhook body size")
 
-    // Загружаем экземпляр с шаблоном (быстрая операция)
-    instance, err := h.repo.GetInstanceWithTemplate(r.Context(), instanceID, "")
+    // Загружаем экземпляр с шаблоном (из кэша; при промахе - из БД)
+    instance, err := h.cache.Instance(r.Context(), instanceID)
     if err != nil {
         log.Error().Err(err).Str("id", instanceID).Msg("Instance not found")
         http.Error(w, "Instance not found", http.StatusNotFound)
         return
     }
 
-    // Сохраняем последний вебхук (быстрая операция)
     headers, _ := json.Marshal(r.Header)
     now := time.Now()
 
-    if err := h.repo.UpdateInstanceLastWebhook(r.Context(), instanceID, headers, body, now); err != nil {
+    // Запись истории доставок для этого вебхука.
+    // История доступна через API, поэтому заголовки с секретами (токены, подписи) маскируются.
+    requestPayload := string(body)
+    loggedHeaders, _ := json.Marshal(payload.RedactHeaders(r.Header))
+    entry := &domain.DeliveryLog{
+        InstanceID:     instanceID,
+        Kind:           domain.DeliveryKindReceived,
+        RequestHeaders: loggedHeaders,
+        RequestPayload: &requestPayload,
+    }
+
+    // Проверяем подпись / секрет до любой обработки запроса
+    if err := h.verify(r, body, instance); err != nil {
+        if errors.Is(err, verification.ErrRejected) {
+            log.Warn().Err(err).Str("instance_id", instanceID).Msg("🚫 Webhook rejected")
+            h.saveLog(entry, domain.DeliveryStatusRejected, err, now)
+            http.Error(w, "Unauthorized", http.StatusUnauthorized)
+            return
+        }
+        log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to verify webhook")
+        h.saveLog(entry, domain.DeliveryStatusError, err, now)
+        http.Error(w, "Internal error", http.StatusInternalServerError)
+        return
+    }
+
+    // Сохраняем последний вебхук (быстрая операция).
+    // Колонка last_webhook_body имеет тип JSONB, поэтому тело не в формате JSON сохраняется JSON-строкой.
+    if err := h.repo.UpdateInstanceLastWebhook(r.Context(), instanceID, headers, payload.StoredBody(body), now); err != nil {
         log.Error().Err(err).Msg("Failed to save last webhook")
         // Не прерываем обработку
     }
@@ -122,110 +172,456 @@ func (h *Handler) HandleInstanceWebhook(w http.ResponseWriter, r *http.Request)
     // Проверяем, активна ли интеграция
     if !instance.IsActive {
         log.Warn().Str("id", instanceID).Msg("Instance is inactive")
+        h.saveLog(entry, domain.DeliveryStatusInactive, nil, now)
         http.Error(w, "Instance is inactive", http.StatusForbidden)
         return
     }
 
-    // Парсим JSON в map для Liquid
-    var data map[string]interface{}
-    if err := json.Unmarshal(body, &data); err != nil {
-        log.Error().Err(err).Msg("Failed to parse JSON")
-        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+    // Разбираем тело в map для Liquid согласно Content-Type (JSON, форма, XML, multipart, текст)
+    contentType := r.Header.Get("Content-Type")
+    data, err := payload.Decode(contentType, body)
+    if err != nil {
+        log.Error().Err(err).Str("content_type", contentType).Msg("Failed to parse payload")
+        h.saveLog(entry, domain.DeliveryStatusError, err, now)
+        http.Error(w, "Invalid payload", http.StatusBadRequest)
         return
     }
-
-    // Логируем входящий запрос
+    payload.Enrich(data, body, r.Header, r.URL.Query(), payload.Meta{
+        InstanceID:   instanceID,
+        InstanceName: instance.Name,
+        ChatID:       instance.ChatID,
+        TemplateName: instance.Template.Name,
+        ReceivedAt:   now,
+        ContentType:  contentType,
+    })
+
+    // Логируем входящий запрос. Только имена полей: в данных есть заголовки с секретами проверки.
     log.Info().
         Str("instance_id", instanceID).
         Str("template", instance.Template.Name).
-        Interface("data", data).
+        Strs("keys", payloadKeys(data)).
         Msg("Processing webhook")
 
-    // Применяем Liquid шаблон
-    engine := liquid.NewEngine()
-    out, err := engine.ParseAndRenderString(instance.Template.TemplateText, data)
+    engine := render.Engine()
+
+    // Правила фильтрации: отброшенные события не отправляются и не занимают ключ дедупликации
+    action, rule, err := h.filter(r, body, instance, data)
     if err != nil {
-        log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to render template")
-        http.Error(w, "Template error", http.StatusInternalServerError)
+        log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to evaluate filter rules")
+        h.saveLog(entry, domain.DeliveryStatusError, err, now)
+        http.Error(w, "Filter error", http.StatusInternalServerError)
+        return
+    }
+    if action == filter.ActionDrop {
+        log.Info().Str("instance_id", instanceID).Int("rule", rule).Msg("🚫 Webhook dropped by filter")
+        h.saveLog(entry, domain.DeliveryStatusDropped, nil, now)
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte(`{"status":"dropped"}`))
         return
     }
 
-    // Расшифровываем токен бота
-    decryptedToken, err := h.encryptor.Decrypt(instance.BotToken)
+    // Повторная доставка того же вебхука (ретраи Jira, GitLab) в окне дедупликации не отправляется
+    dedupKey, duplicate, err := h.claimDedupKey(r, body, instance, engine, data)
     if err != nil {
-        log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to decrypt bot token")
+        log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to check webhook duplicate")
+        h.saveLog(entry, domain.DeliveryStatusError, err, now)
         http.Error(w, "Internal error", http.StatusInternalServerError)
         return
     }
+    if duplicate {
+        log.Info().Str("instance_id", instanceID).Str("dedup_key", dedupKey).Msg("🔁 Duplicate webhook suppressed")
+        h.saveLog(entry, domain.DeliveryStatusDuplicate, nil, now)
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte(`{"status":"duplicate"}`))
+        return
+    }
+
+    // Если вебхук не был обработан до конца, ключ освобождается, чтобы ретрай источника прошёл
+    handled := false
+    defer func() {
+        if dedupKey != "" && !handled {
+            h.releaseDedupKey(instanceID, dedupKey)
+        }
+    }()
 
-    // ========== АСИНХРОННАЯ ОТПРАВКА ==========
-    // Отправляем сообщение в фоне, не блокируя ответ клиенту
-    go h.sendMessageAsync(instanceID, instance.ChatID, decryptedToken, out)
+    // Уведомление Alertmanager разбивается на сообщения по группе или по алертам
+    amSettings, events, err := h.events(instance, data)
+    if err != nil {
+        log.Error().Err(err).Str("instance_id", instanceID).Msg("Invalid Alertmanager payload")
+        h.saveLog(entry, domain.DeliveryStatusError, err, now)
+        http.Error(w, "Invalid Alertmanager payload", http.StatusBadRequest)
+        return
+    }
+
+    // Применяем Liquid шаблон ко всем сообщениям до постановки в очередь,
+    // чтобы ошибка шаблона не оставляла уведомление отправленным наполовину
+    pending, renderErr := h.renderMessages(r, body, instance, engine, amSettings, events)
+    if renderErr != nil {
+        log.Error().Err(renderErr).Str("instance_id", instanceID).Str("route", renderErr.route).Msg("Failed to render messages")
+        if renderErr.rendered != "" {
+            entry.RenderedText = &renderErr.rendered
+        }
+        h.saveLog(entry, domain.DeliveryStatusError, renderErr.err, now)
+        http.Error(w, renderErr.response(), http.StatusInternalServerError)
+        return
+    }
+
+    if len(pending) == 0 && len(events) > 0 {
+        log.Info().Str("instance_id", instanceID).Msg("🚫 Nothing to send, event dropped")
+        handled = true
+        h.saveLog(entry, domain.DeliveryStatusDropped, nil, now)
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte(`{"status":"dropped"}`))
+        return
+    }
+
+    // ========== ОТПРАВКА ЧЕРЕЗ ОЧЕРЕДЬ ==========
+    // Сообщение сохраняется в delivery_outbox и отправляется пулом воркеров,
+    // поэтому рестарт пода не приводит к потере сообщений
+    var messages []*domain.OutboxMessage
+    var queued []string
+    states := make(map[int]alertState, len(events))
+    for _, p := range pending {
+        // Повтор Alertmanager по repeat_interval без изменений не отправляем.
+        // Состояние проверяется один раз на событие, даже если оно ушло по нескольким маршрутам.
+        state, checked := states[p.event]
+        if !checked {
+            state.key, state.fresh, err = h.touchAlertState(r.Context(), instanceID, amSettings, events[p.event])
+            if err != nil {
+                log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to check alert state")
+                h.resetAlertStates(instanceID, states)
+                h.saveLog(entry, domain.DeliveryStatusError, err, now)
+                http.Error(w, "Internal error", http.StatusInternalServerError)
+                return
+            }
+            states[p.event] = state
+        }
+        if !state.fresh {
+            log.Info().Str("instance_id", instanceID).Str("state_key", state.key).Msg("🔁 Alertmanager repeat suppressed")
+            continue
+        }
+
+        messages = append(messages, p.msg)
+        queued = append(queued, p.rendered)
+    }
 
-    // Немедленно возвращаем успешный ответ
+    // Все сообщения вебхука (алерты, маршруты, личные сообщения) ставятся в очередь одной транзакцией:
+    // при ошибке не ставится ни одно, и ретрай источника не приводит к дублям
+    if len(messages) > 0 {
+        if err := h.repo.EnqueueOutbox(r.Context(), messages); err != nil {
+            log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to enqueue messages")
+            // Состояния сбрасываем, чтобы повтор уведомления от Alertmanager не был отброшен
+            h.resetAlertStates(instanceID, states)
+            out := strings.Join(queued, "\n\n")
+            entry.RenderedText = &out
+            h.saveLog(entry, domain.DeliveryStatusError, err, now)
+            http.Error(w, "Internal error", http.StatusInternalServerError)
+            return
+        }
+
+        for _, msg := range messages {
+            log.Info().
+                Str("instance_id", instanceID).
+                Int64("outbox_id", msg.ID).
+                Msg("📮 Message queued for delivery")
+        }
+        entry.OutboxID = &messages[0].ID
+    }
+
+    if len(queued) == 0 {
+        h.saveLog(entry, domain.DeliveryStatusSuppressed, nil, now)
+    } else {
+        out := strings.Join(queued, "\n\n")
+        entry.RenderedText = &out
+        h.saveLog(entry, domain.DeliveryStatusAccepted, nil, now)
+    }
+
+    handled = true
     w.WriteHeader(http.StatusOK)
     w.Write([]byte(`{"status":"ok"}`))
 }
 
-// sendMessageAsync асинхронно отправляет сообщение в Яндекс Мессенджер
-func (h *Handler) sendMessageAsync(instanceID, chatID, token, message string) {
-    // Создаем отдельный контекст с увеличенным таймаутом для отправки
-    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// pendingMessage - отрендеренное сообщение, ожидающее постановки в очередь
+type pendingMessage struct {
+    event    int    // индекс события Alertmanager
+    route    string // имя маршрута, пустое для чата экземпляра
+    rendered string
+    msg      *domain.OutboxMessage
+}
+
+// alertState - результат проверки состояния события Alertmanager
+type alertState struct {
+    key   string
+    fresh bool
+}
+
+// renderError - ошибка рендеринга сообщений с этапом, на котором она произошла
+type renderError struct {
+    stage    string // correlation, routing, template, front_matter
+    route    string // маршрут, шаблон которого не отрендерился
+    rendered string // результат рендеринга при ошибке front matter
+    err      error
+}
+
+func (e *renderError) Error() string {
+    return e.stage + ": " + e.err.Error()
+}
+
+// response возвращает текст ответа источнику вебхука
+func (e *renderError) response() string {
+    if e.stage == "routing" {
+        return "Routing error"
+    }
+    return "Template error"
+}
+
+// renderMessages вычисляет ключи корреляции, получателей и текст сообщений для всех событий.
+// Пустые результаты рендеринга пропускаются.
+func (h *Handler) renderMessages(r *http.Request, body []byte, instance *domain.IntegrationInstance, engine *liquid.Engine, amSettings *alertmanager.Settings, events []alertmanager.Event) ([]pendingMessage, *renderError) {
+    var pending []pendingMessage
+    for i, event := range events {
+        // Ключ корреляции: повторные события по одному объекту попадают в тред первого сообщения
+        correlationKey := event.Key
+        if amSettings == nil {
+            var err error
+            correlationKey, err = h.correlationKey(engine, instance, event.Data)
+            if err != nil {
+                return nil, &renderError{stage: "correlation", err: err}
+            }
+        }
+
+        // Маршруты: одно событие может уйти в несколько чатов со своими шаблонами
+        targets, err := h.targets(r, body, instance, engine, event.Data)
+        if err != nil {
+            return nil, &renderError{stage: "routing", err: err}
+        }
+
+        for _, target := range targets {
+            // Разобранные шаблоны берём из кэша: ключ меняется вместе с updated_at шаблона или экземпляра
+            templateKey, templateText := cache.TemplateKey(instance.Template), instance.Template.TemplateText
+            if target.Template != "" {
+                templateKey, templateText = cache.RouteTemplateKey(instance, target.Template), target.Template
+            }
+
+            var out string
+            tpl, err := h.cache.Template(templateKey, templateText)
+            if err == nil {
+                out, err = tpl.RenderString(event.Data)
+            }
+            if err != nil {
+                return nil, &renderError{stage: "template", route: target.Route, err: err}
+            }
+
+            // Отделяем front matter с кнопками и вложениями от текста
+            text, options, err := message.Parse(out)
+            if err != nil {
+                return nil, &renderError{stage: "front_matter", route: target.Route, rendered: out, err: err}
+            }
+
+            // Пустой результат рендеринга никогда не отправляется
+            if message.IsBlank(text, options) {
+                continue
+            }
+
+            pending = append(pending, pendingMessage{
+                event:    i,
+                route:    target.Route,
+                rendered: out,
+                msg: &domain.OutboxMessage{
+                    InstanceID:  instance.ID,
+                    ChatID:      target.ChatID,
+                    Login:       target.Login,
+                    Message:     text,
+                    Options:     options,
+                    MaxAttempts: h.config.MaxRetries + 1,
+
+                    CorrelationKey: correlationKey,
+                    CloseThread:    event.Final && correlationKey != "",
+                },
+            })
+        }
+    }
+    return pending, nil
+}
+
+// targets возвращает получателей события: чаты по маршрутам экземпляра (без маршрутов - чат экземпляра)
+// и, в режиме личных сообщений, пользователей из тела вебхука
+func (h *Handler) targets(r *http.Request, body []byte, instance *domain.IntegrationInstance, engine *liquid.Engine, data map[string]interface{}) ([]routing.Target, error) {
+    chats, err := h.routeTargets(r, body, instance, data)
+    if err != nil {
+        return nil, err
+    }
+
+    direct, err := recipients.FromCustomSettings(instance.CustomSettings)
+    if err != nil || direct == nil {
+        return chats, err
+    }
+
+    logins, unresolved, err := direct.Resolve(engine, data)
+    if err != nil {
+        return nil, err
+    }
+    if len(unresolved) > 0 {
+        log.Warn().Str("instance_id", instance.ID).Strs("names", unresolved).Msg("No Yandex 360 login for recipients")
+    }
+
+    var targets []routing.Target
+    if direct.KeepChat {
+        targets = chats
+    }
+    for _, login := range logins {
+        targets = append(targets, routing.Target{Login: login})
+    }
+    return targets, nil
+}
+
+// routeTargets возвращает чаты по маршрутам экземпляра. Без маршрутов - чат экземпляра.
+func (h *Handler) routeTargets(r *http.Request, body []byte, instance *domain.IntegrationInstance, data map[string]interface{}) ([]routing.Target, error) {
+    rules := h.cache.Rules(instance)
+    if rules.RouterErr != nil {
+        return nil, rules.RouterErr
+    }
+    if rules.Router == nil {
+        return []routing.Target{{ChatID: instance.ChatID}}, nil
+    }
+    return rules.Router.Resolve(filter.Input{Header: r.Header, Body: body, Data: data}, instance.ChatID)
+}
+
+// filter применяет правила фильтрации экземпляра. Без правил событие отправляется.
+func (h *Handler) filter(r *http.Request, body []byte, instance *domain.IntegrationInstance, data map[string]interface{}) (string, int, error) {
+    rules := h.cache.Rules(instance)
+    if rules.FilterErr != nil {
+        return "", 0, rules.FilterErr
+    }
+    if rules.Filter == nil {
+        return filter.ActionSend, 0, nil
+    }
+    return rules.Filter.Evaluate(filter.Input{Header: r.Header, Body: body, Data: data})
+}
+
+// events возвращает сообщения, которые нужно отправить по вебхуку.
+// Без режима Alertmanager это одно сообщение по всему телу запроса.
+func (h *Handler) events(instance *domain.IntegrationInstance, data map[string]interface{}) (*alertmanager.Settings, []alertmanager.Event, error) {
+    settings, err := alertmanager.FromCustomSettings(instance.CustomSettings)
+    if err != nil {
+        return nil, nil, err
+    }
+    if settings == nil {
+        return nil, []alertmanager.Event{{Data: data}}, nil
+    }
+
+    events, err := settings.Events(data)
+    if err != nil {
+        return nil, nil, err
+    }
+    return settings, events, nil
+}
+
+// touchAlertState отмечает отправку состояния группы или алерта.
+// Возвращает ключ сохранённого состояния и false, если это повтор без изменений.
+func (h *Handler) touchAlertState(ctx context.Context, instanceID string, settings *alertmanager.Settings, event alertmanager.Event) (string, bool, error) {
+    if settings == nil || !settings.Dedup || event.StateKey == "" {
+        return "", true, nil
+    }
+
+    fresh, err := h.repo.TouchAlertState(ctx, instanceID, event.StateKey, event.Hash, time.Now().Add(settings.Duration()))
+    if err != nil {
+        return "", false, err
+    }
+    return event.StateKey, fresh, nil
+}
+
+// claimDedupKey занимает ключ идемпотентности вебхука.
+// Возвращает пустой ключ, если дедупликация выключена, и duplicate = true для повтора в окне.
+func (h *Handler) claimDedupKey(r *http.Request, body []byte, instance *domain.IntegrationInstance, engine *liquid.Engine, data map[string]interface{}) (string, bool, error) {
+    settings, err := dedup.FromCustomSettings(instance.CustomSettings)
+    if err != nil || settings == nil {
+        return "", false, err
+    }
+
+    key, err := settings.KeyFor(r.Header, body, engine, data)
+    if err != nil {
+        return "", false, err
+    }
+
+    claimed, err := h.repo.ClaimDedupKey(r.Context(), instance.ID, key, time.Now().Add(settings.Duration()))
+    if err != nil {
+        return "", false, err
+    }
+    return key, !claimed, nil
+}
+
+// resetAlertStates удаляет состояния событий Alertmanager, отмеченные этим вебхуком,
+// если его сообщения не попали в очередь. Иначе повтор уведомления был бы отброшен как уже отправленный.
+func (h *Handler) resetAlertStates(instanceID string, states map[int]alertState) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    yandexClient := yandex.NewClient(token)
+    for _, state := range states {
+        if state.key == "" || !state.fresh {
+            continue
+        }
+        if err := h.repo.DeleteAlertState(ctx, instanceID, state.key); err != nil {
+            log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to reset alert state")
+        }
+    }
+}
+
+// releaseDedupKey освобождает ключ идемпотентности необработанного вебхука
+func (h *Handler) releaseDedupKey(instanceID, key string) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
 
-    startTime := time.Now()
-    err := yandexClient.SendToChat(ctx, chatID, message, nil)
-    duration := time.Since(startTime)
+    if err := h.repo.ReleaseDedupKey(ctx, instanceID, key); err != nil {
+        log.Error().Err(err).Str("instance_id", instanceID).Msg("Failed to release dedup key")
+    }
+}
 
+// verify проверяет запрос согласно настройкам проверки экземпляра
+func (h *Handler) verify(r *http.Request, body []byte, instance *domain.IntegrationInstance) error {
+    settings, err := verification.FromCustomSettings(instance.CustomSettings)
     if err != nil {
-        log.Error().
-            Err(err).
-            Str("instance_id", instanceID).
-            Dur("duration", duration).
-            Msg("❌ Failed to send message asynchronously")
-
-        // Можно добавить retry логику здесь
-        h.retrySendAsync(instanceID, chatID, token, message, 1)
-    } else {
-        log.Info().
-            Str("instance_id", instanceID).
-            Dur("duration", duration).
-            Msg("✅ Message sent successfully asynchronously")
+        return err
     }
+    return h.verifier.Verify(r, body, settings)
 }
 
-// retrySendAsync повторяет отправку при ошибке (опционально)
-func (h *Handler) retrySendAsync(instanceID, chatID, token, message string, attempt int) {
-    if attempt > h.config.MaxRetries {
-        log.Error().
-            Str("instance_id", instanceID).
-            Int("attempts", attempt-1).
-            Msg("❌ Max retries reached, message lost")
-        return
+// correlationKey вычисляет ключ корреляции по настройкам экземпляра (пустая строка - без группировки)
+func (h *Handler) correlationKey(engine *liquid.Engine, instance *domain.IntegrationInstance, data map[string]interface{}) (string, error) {
+    settings, err := correlation.FromCustomSettings(instance.CustomSettings)
+    if err != nil || settings == nil {
+        return "", err
     }
+    return settings.RenderKey(engine, data)
+}
 
-    // Экспоненциальная задержка: 2s, 4s, 8s
-    delay := time.Duration(1<<uint(attempt)) * time.Second
-    time.Sleep(delay)
+// payloadKeys возвращает отсортированные имена полей тела вебхука без переменных запроса
+func payloadKeys(data map[string]interface{}) []string {
+    keys := make([]string, 0, len(data))
+    for key := range data {
+        if payload.IsRequestKey(key) {
+            continue
+        }
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
 
-    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// saveLog сохраняет запись о входящем вебхуке в историю доставок.
+// Используется собственный контекст: контекст запроса ограничен таймаутом чтения.
+func (h *Handler) saveLog(entry *domain.DeliveryLog, status string, procErr error, started time.Time) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    yandexClient := yandex.NewClient(token)
+    entry.Status = status
+    entry.DurationMS = int(time.Since(started).Milliseconds())
+    if procErr != nil {
+        errText := procErr.Error()
+        entry.Error = &errText
+    }
 
-    if err := yandexClient.SendToChat(ctx, chatID, message, nil); err != nil {
-        log.Error().
-            Err(err).
-            Str("instance_id", instanceID).
-            Int("attempt", attempt+1).
-            Msg("❌ Retry failed")
-        h.retrySendAsync(instanceID, chatID, token, message, attempt+1)
-    } else {
-        log.Info().
-            Str("instance_id", instanceID).
-            Int("attempt", attempt).
-            Msg("✅ Message sent successfully on retry")
+    if err := h.repo.CreateDeliveryLog(ctx, entry); err != nil {
+        log.Error().Err(err).Str("instance_id", entry.InstanceID).Msg("Failed to save delivery log")
     }
-}
\ No newline at end of file
+}
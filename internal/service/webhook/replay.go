@@ -0,0 +1,141 @@
+// Путь: internal/service/webhook/replay.go
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/payload"
+	"yandex-messenger-bridge/internal/service/render"
+)
+
+// Ошибки проверки на последнем вебхуке
+var (
+	ErrNoLastWebhook = errors.New("no webhooks received yet")
+	ErrNoTemplate    = errors.New("instance has no template")
+)
+
+// Статусы проверки на последнем вебхуке
+const (
+	ReplayStatusSent    = "sent"    // все сообщения отправлены
+	ReplayStatusFailed  = "failed"  // хотя бы одно сообщение не отправлено
+	ReplayStatusDropped = "dropped" // событие отброшено фильтром или шаблон дал пустой результат
+	ReplayStatusError   = "error"   // ошибка разбора, фильтров, маршрутов или шаблона
+)
+
+// ReplayResult - результат проверки интеграции на последнем вебхуке
+type ReplayResult struct {
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Messages []ReplayMessage `json:"messages"`
+}
+
+// ReplayMessage - одно сообщение проверки и результат его отправки
+type ReplayMessage struct {
+	Route      string `json:"route,omitempty"`
+	ChatID     string `json:"chat_id,omitempty"`
+	Login      string `json:"login,omitempty"`
+	Rendered   string `json:"rendered"`
+	MessageID  int64  `json:"message_id,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Replay прогоняет сохранённый последний вебхук через весь путь обработки - разбор, фильтры,
+// разбиение Alertmanager, маршруты и шаблоны - и сразу отправляет результат, минуя очередь.
+// Дедупликация, подавление повторов Alertmanager и группировка по ключу корреляции не применяются:
+// проверочное сообщение не должно подавляться и не должно отвечать в тред или заменять настоящие сообщения.
+// Подпись не проверяется - запрос проверялся при получении.
+func (h *Handler) Replay(ctx context.Context, instance *domain.IntegrationInstance) (*ReplayResult, error) {
+	if instance.LastWebhookAt == nil {
+		return nil, ErrNoLastWebhook
+	}
+	if instance.Template == nil {
+		return nil, ErrNoTemplate
+	}
+
+	body, header := payload.Stored(instance.LastWebhookHeaders, instance.LastWebhookBody)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/webhook/instance/"+instance.ID, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header = header
+
+	result := &ReplayResult{Messages: []ReplayMessage{}}
+	fail := func(err error) (*ReplayResult, error) {
+		result.Status, result.Error = ReplayStatusError, err.Error()
+		return result, nil
+	}
+
+	contentType := header.Get("Content-Type")
+	data, err := payload.Decode(contentType, body)
+	if err != nil {
+		return fail(err)
+	}
+	payload.Enrich(data, body, header, r.URL.Query(), payload.Meta{
+		InstanceID:   instance.ID,
+		InstanceName: instance.Name,
+		ChatID:       instance.ChatID,
+		TemplateName: instance.Template.Name,
+		ReceivedAt:   time.Now(),
+		ContentType:  contentType,
+	})
+
+	engine := render.Engine()
+
+	action, _, err := h.filter(r, body, instance, data)
+	if err != nil {
+		return fail(err)
+	}
+	if action == filter.ActionDrop {
+		result.Status = ReplayStatusDropped
+		return result, nil
+	}
+
+	amSettings, events, err := h.events(instance, data)
+	if err != nil {
+		return fail(err)
+	}
+
+	pending, renderErr := h.renderMessages(r, body, instance, engine, amSettings, events)
+	if renderErr != nil {
+		return fail(renderErr)
+	}
+	if len(pending) == 0 {
+		result.Status = ReplayStatusDropped
+		return result, nil
+	}
+
+	result.Status = ReplayStatusSent
+	for _, p := range pending {
+		msg := p.msg
+		msg.CorrelationKey = ""
+
+		item := ReplayMessage{
+			Route:    p.route,
+			ChatID:   msg.ChatID,
+			Login:    msg.Login,
+			Rendered: p.rendered,
+		}
+		sent, err := h.sender.Send(ctx, msg)
+		if sent != nil {
+			item.MessageID, item.StatusCode = sent.MessageID, sent.StatusCode
+		}
+		if err != nil {
+			log.Error().Err(err).Str("instance_id", instance.ID).Str("recipient", msg.Recipient()).Msg("Replay message failed")
+			item.Error = err.Error()
+			result.Status = ReplayStatusFailed
+		}
+		result.Messages = append(result.Messages, item)
+	}
+
+	log.Info().Str("instance_id", instance.ID).Str("status", result.Status).Int("messages", len(result.Messages)).Msg("🔁 Last webhook replayed")
+	return result, nil
+}
@@ -0,0 +1,878 @@
+// Путь: internal/transport/api/instances.go
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/alertmanager"
+	"yandex-messenger-bridge/internal/service/correlation"
+	"yandex-messenger-bridge/internal/service/dedup"
+	"yandex-messenger-bridge/internal/service/encryption"
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/fork"
+	"yandex-messenger-bridge/internal/service/payload"
+	"yandex-messenger-bridge/internal/service/preview"
+	"yandex-messenger-bridge/internal/service/recipients"
+	"yandex-messenger-bridge/internal/service/routing"
+	"yandex-messenger-bridge/internal/service/templatetest"
+	"yandex-messenger-bridge/internal/service/verification"
+	"yandex-messenger-bridge/internal/service/webhook"
+	"yandex-messenger-bridge/internal/yandex"
+)
+
+// InstanceAPI - JSON API для экземпляров интеграций
+type InstanceAPI struct {
+	repo      _interface.IntegrationRepository
+	encryptor *encryption.Encryptor
+	webhooks  *webhook.Handler
+}
+
+// CreateInstanceRequest - параметры создания экземпляра.
+// Если template_id не указан, из template_text создаётся приватный шаблон пользователя.
+type CreateInstanceRequest struct {
+	TemplateID   string                 `json:"template_id"`
+	TemplateText string                 `json:"template_text"`
+	Name         string                 `json:"name"`
+	ChatID       string                 `json:"chat_id"`
+	BotToken     string                 `json:"bot_token"`
+	IsActive     *bool                  `json:"is_active"`
+	Verification *VerificationRequest   `json:"verification"`
+	Correlation  *correlation.Settings  `json:"correlation"`
+	Alertmanager *alertmanager.Settings `json:"alertmanager"`
+	Dedup        *dedup.Settings        `json:"dedup"`
+	Filters      *filter.Settings       `json:"filters"`
+	Routes       *routing.Settings      `json:"routes"`
+	Direct       *recipients.Settings   `json:"direct"`
+}
+
+// UpdateInstanceRequest - частичное обновление экземпляра, незаданные поля не меняются.
+// TemplateVersion закрепляет экземпляр за версией шаблона, 0 - следовать последней версии.
+// TemplateText меняет шаблон экземпляра; общий шаблон при этом копируется в личный.
+type UpdateInstanceRequest struct {
+	Name            *string                `json:"name"`
+	TemplateText    *string                `json:"template_text"`
+	ChatID          *string                `json:"chat_id"`
+	IsActive        *bool                  `json:"is_active"`
+	Verification    *VerificationRequest   `json:"verification"`
+	Correlation     *correlation.Settings  `json:"correlation"`
+	Alertmanager    *alertmanager.Settings `json:"alertmanager"`
+	Dedup           *dedup.Settings        `json:"dedup"`
+	Filters         *filter.Settings       `json:"filters"`
+	Routes          *routing.Settings      `json:"routes"`
+	Direct          *recipients.Settings   `json:"direct"`
+	TemplateVersion *int                   `json:"template_version"`
+}
+
+// VerificationRequest - настройки проверки вебхуков. Секрет передаётся в открытом виде
+// и сохраняется зашифрованным; пустой секрет оставляет текущий.
+type VerificationRequest struct {
+	Mode       string   `json:"mode"`
+	Secret     string   `json:"secret"`
+	Username   string   `json:"username"`
+	Header     string   `json:"header"`
+	Algorithm  string   `json:"algorithm"`
+	AllowedIPs []string `json:"allowed_ips"`
+}
+
+// RotateTokenRequest - новый токен бота
+type RotateTokenRequest struct {
+	BotToken string `json:"bot_token"`
+}
+
+func NewInstanceAPI(repo _interface.IntegrationRepository, encryptor *encryption.Encryptor, webhooks *webhook.Handler) *InstanceAPI {
+	return &InstanceAPI{
+		repo:      repo,
+		encryptor: encryptor,
+		webhooks:  webhooks,
+	}
+}
+
+// loadInstance загружает экземпляр текущего пользователя вместе с шаблоном
+func (api *InstanceAPI) loadInstance(c echo.Context) (*domain.IntegrationInstance, error) {
+	userID := c.Get("user_id").(string)
+	instance, err := api.repo.GetInstanceWithTemplate(c.Request().Context(), c.Param("id"), userID)
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+	return instance, nil
+}
+
+// List возвращает экземпляры пользователя
+// GET /api/v1/instances
+func (api *InstanceAPI) List(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	instances, err := api.repo.ListInstances(c.Request().Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list instances")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list instances"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  instances,
+		"total": len(instances),
+	})
+}
+
+// Get возвращает экземпляр
+// GET /api/v1/instances/:id
+func (api *InstanceAPI) Get(c echo.Context) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, redactInstance(instance))
+}
+
+// redactInstance скрывает значения заголовков с секретами в последнем вебхуке экземпляра перед ответом.
+// Исходные заголовки остаются в базе только для повторной отправки.
+func redactInstance(instance *domain.IntegrationInstance) *domain.IntegrationInstance {
+	instance.LastWebhookHeaders = payload.RedactStoredHeaders(instance.LastWebhookHeaders)
+	return instance
+}
+
+// Create создает экземпляр из существующего шаблона или из текста шаблона
+// POST /api/v1/instances
+func (api *InstanceAPI) Create(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req CreateInstanceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if req.Name == "" || req.ChatID == "" || req.BotToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name, chat_id and bot_token are required"})
+	}
+	if req.TemplateID == "" && req.TemplateText == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "template_id or template_text is required"})
+	}
+
+	instance := &domain.IntegrationInstance{
+		UserID:         userID,
+		Name:           req.Name,
+		ChatID:         req.ChatID,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+		CustomSettings: map[string]interface{}{},
+	}
+
+	if req.Verification != nil {
+		if err := api.applyVerification(instance, req.Verification); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Correlation != nil {
+		if err := applyCorrelation(instance, req.Correlation); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Alertmanager != nil {
+		if err := applyAlertmanager(instance, req.Alertmanager); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Dedup != nil {
+		if err := applyDedup(instance, req.Dedup); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Filters != nil {
+		if err := applyFilters(instance, req.Filters); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Routes != nil {
+		if err := applyRoutes(instance, req.Routes); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Direct != nil {
+		if err := applyDirect(instance, req.Direct); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if req.TemplateID != "" {
+		template, err := api.repo.GetTemplateByID(c.Request().Context(), req.TemplateID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+		}
+		if !template.IsPublic && template.CreatedBy.String != userID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+		}
+		instance.TemplateID = template.ID
+	} else {
+		// Приватный шаблон, как при создании кастомной интеграции в UI
+		template := &domain.Template{
+			Name:         req.Name + " (кастомный)",
+			Icon:         "📝",
+			Description:  "Кастомная интеграция",
+			TemplateText: req.TemplateText,
+			IsPublic:     false,
+			CreatedBy:    sql.NullString{String: userID, Valid: true},
+		}
+		if err := api.repo.CreateTemplate(c.Request().Context(), template); err != nil {
+			log.Error().Err(err).Msg("Failed to create custom template")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create template"})
+		}
+		instance.TemplateID = template.ID
+	}
+
+	// Шифруем токен перед сохранением
+	encryptedToken, err := api.encryptor.Encrypt(req.BotToken)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encrypt bot token")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encrypt token"})
+	}
+	instance.BotToken = encryptedToken
+
+	if err := api.repo.CreateInstance(c.Request().Context(), instance); err != nil {
+		log.Error().Err(err).Msg("Failed to create instance")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create instance"})
+	}
+
+	log.Info().Str("id", instance.ID).Str("name", instance.Name).Msg("Instance created via API")
+
+	created, err := api.repo.GetInstanceWithTemplate(c.Request().Context(), instance.ID, userID)
+	if err != nil {
+		return c.JSON(http.StatusCreated, map[string]string{"id": instance.ID})
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Update частично обновляет экземпляр
+// PUT /api/v1/instances/:id
+func (api *InstanceAPI) Update(c echo.Context) error {
+	var req UpdateInstanceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	if req.Name != nil {
+		instance.Name = *req.Name
+	}
+	if req.ChatID != nil {
+		instance.ChatID = *req.ChatID
+	}
+	if req.IsActive != nil {
+		instance.IsActive = *req.IsActive
+	}
+	if req.Verification != nil {
+		if err := api.applyVerification(instance, req.Verification); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Correlation != nil {
+		if err := applyCorrelation(instance, req.Correlation); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Alertmanager != nil {
+		if err := applyAlertmanager(instance, req.Alertmanager); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Dedup != nil {
+		if err := applyDedup(instance, req.Dedup); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Filters != nil {
+		if err := applyFilters(instance, req.Filters); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Routes != nil {
+		if err := applyRoutes(instance, req.Routes); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+	if req.Direct != nil {
+		if err := applyDirect(instance, req.Direct); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if req.TemplateVersion != nil {
+		if err := api.applyTemplateVersion(c, instance, *req.TemplateVersion); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if instance.Name == "" || instance.ChatID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name and chat_id must not be empty"})
+	}
+
+	if req.TemplateText != nil && instance.Template != nil && *req.TemplateText != instance.Template.TemplateText {
+		if template, err := api.updateTemplateText(c, instance, *req.TemplateText); template == nil {
+			return err
+		}
+	}
+
+	return api.save(c, instance)
+}
+
+// updateTemplateText меняет текст шаблона экземпляра. Общий шаблон не меняется:
+// создаётся личная копия, и экземпляр переключается на неё.
+// Возвращает новый шаблон экземпляра; nil означает, что ответ с ошибкой уже отправлен.
+func (api *InstanceAPI) updateTemplateText(c echo.Context, instance *domain.IntegrationInstance, text string) (*domain.Template, error) {
+	userID := c.Get("user_id").(string)
+	if text == "" {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "template_text must not be empty"})
+	}
+	if instance.TemplateVersion != nil {
+		return nil, c.JSON(http.StatusConflict, map[string]string{"error": "template is pinned to a version, set template_version to 0 first"})
+	}
+
+	if fork.Shared(instance.Template, userID) {
+		original := instance.Template
+		copied, err := fork.Instance(c.Request().Context(), api.repo, instance, userID, text)
+		if err != nil {
+			log.Error().Err(err).Str("template_id", original.ID).Msg("Failed to fork template")
+			return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fork template"})
+		}
+		log.Info().Str("id", instance.ID).Str("template_id", original.ID).Str("fork_id", copied.ID).Msg("Shared template forked for instance via API")
+		return copied, nil
+	}
+
+	template := instance.Template
+	template.TemplateText = text
+	template.ChangeAuthor = userID
+	template.ChangeNote = "Изменено в интеграции " + instance.Name
+	if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+	if report, err := templatetest.Check(template); err != nil {
+		return nil, c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": err.Error(),
+			"tests": report,
+		})
+	}
+	if err := api.repo.UpdateTemplate(c.Request().Context(), template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to update template")
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update template"})
+	}
+	return template, nil
+}
+
+// applyTemplateVersion закрепляет экземпляр за существующей версией шаблона (0 - последняя версия)
+func (api *InstanceAPI) applyTemplateVersion(c echo.Context, instance *domain.IntegrationInstance, version int) error {
+	if version == 0 {
+		// В instance.Template подставлена закреплённая версия - возвращаем последнюю
+		if instance.TemplateVersion != nil && instance.Template != nil {
+			template, err := api.repo.GetTemplateByID(c.Request().Context(), instance.Template.ID)
+			if err != nil {
+				return fmt.Errorf("template not found")
+			}
+			instance.Template = template
+		}
+		instance.TemplateVersion = nil
+		return nil
+	}
+	if instance.Template == nil {
+		return fmt.Errorf("instance has no template")
+	}
+	if _, err := api.repo.GetTemplateVersion(c.Request().Context(), instance.Template.ID, version); err != nil {
+		return fmt.Errorf("template version %d not found", version)
+	}
+	instance.TemplateVersion = &version
+	return nil
+}
+
+// Delete удаляет экземпляр
+// DELETE /api/v1/instances/:id
+func (api *InstanceAPI) Delete(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	if err := api.repo.DeleteInstance(c.Request().Context(), id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+		}
+		log.Error().Err(err).Str("id", id).Msg("Failed to delete instance")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete instance"})
+	}
+
+	log.Info().Str("id", id).Msg("Instance deleted via API")
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Enable включает экземпляр
+// POST /api/v1/instances/:id/enable
+func (api *InstanceAPI) Enable(c echo.Context) error {
+	return api.setActive(c, true)
+}
+
+// Disable выключает экземпляр: вебхуки принимаются, но сообщения не отправляются
+// POST /api/v1/instances/:id/disable
+func (api *InstanceAPI) Disable(c echo.Context) error {
+	return api.setActive(c, false)
+}
+
+// setActive меняет признак активности экземпляра
+func (api *InstanceAPI) setActive(c echo.Context, active bool) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	instance.IsActive = active
+	return api.save(c, instance)
+}
+
+// RotateToken заменяет токен бота
+// POST /api/v1/instances/:id/rotate-token
+func (api *InstanceAPI) RotateToken(c echo.Context) error {
+	var req RotateTokenRequest
+	if err := c.Bind(&req); err != nil || req.BotToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "bot_token is required"})
+	}
+
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	encryptedToken, err := api.encryptor.Encrypt(req.BotToken)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encrypt bot token")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to encrypt token"})
+	}
+	instance.BotToken = encryptedToken
+
+	log.Info().Str("id", instance.ID).Msg("Bot token rotated")
+	return api.save(c, instance)
+}
+
+// LastWebhook возвращает последний полученный вебхук
+// GET /api/v1/instances/:id/last-webhook
+func (api *InstanceAPI) LastWebhook(c echo.Context) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	if instance.LastWebhookAt == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no webhooks received yet"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"received_at": instance.LastWebhookAt,
+		"headers":     payload.RedactStoredHeaders(instance.LastWebhookHeaders),
+		"body":        instance.LastWebhookBody,
+	})
+}
+
+// Preview рендерит шаблон экземпляра (или template_text) на последнем вебхуке, примере данных шаблона
+// или переданных данных, как при обработке вебхука
+// POST /api/v1/instances/:id/preview
+func (api *InstanceAPI) Preview(c echo.Context) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	var req PreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	text := req.TemplateText
+	if text == "" && instance.Template != nil {
+		text = instance.Template.TemplateText
+	}
+	if err := loadSamples(c.Request().Context(), api.repo, instance.Template); err != nil {
+		log.Error().Err(err).Str("instance_id", instance.ID).Msg("Failed to load template samples")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+
+	return c.JSON(http.StatusOK, preview.Preview(text, req.Source, req.Sample, req.pasted(), instance, instance.Template))
+}
+
+// Test отправляет тестовое сообщение в чат экземпляра
+// POST /api/v1/instances/:id/test
+func (api *InstanceAPI) Test(c echo.Context) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	// Токен в экземпляре хранится зашифрованным
+	token, err := api.encryptor.Decrypt(instance.BotToken)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to decrypt bot token")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to decrypt token"})
+	}
+
+	testMessage := fmt.Sprintf("🔄 *Тестовое сообщение*\n\nЭкземпляр: *%s*\nВремя: *%s*",
+		instance.Name,
+		time.Now().Format("02.01.2006 15:04:05"))
+
+	result, err := yandex.NewClient(token).SendText(c.Request().Context(), yandex.SendMessageRequest{
+		ChatID: instance.ChatID,
+		Text:   testMessage,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", instance.ID).Msg("Test message failed")
+		return c.JSON(http.StatusBadGateway, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"message_id": result.MessageID,
+	})
+}
+
+// Replay прогоняет последний полученный вебхук через фильтры, маршруты и шаблон и отправляет результат
+// POST /api/v1/instances/:id/replay
+func (api *InstanceAPI) Replay(c echo.Context) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	result, err := api.webhooks.Replay(c.Request().Context(), instance)
+	switch {
+	case errors.Is(err, webhook.ErrNoLastWebhook), errors.Is(err, webhook.ErrNoTemplate):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		log.Error().Err(err).Str("instance_id", instance.ID).Msg("Replay failed")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to replay last webhook"})
+	}
+
+	status := http.StatusOK
+	if result.Status == webhook.ReplayStatusFailed {
+		status = http.StatusBadGateway
+	}
+	return c.JSON(status, result)
+}
+
+// applyVerification переносит настройки проверки вебхуков в CustomSettings
+func (api *InstanceAPI) applyVerification(instance *domain.IntegrationInstance, req *VerificationRequest) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	settings := &verification.Settings{
+		Mode:       req.Mode,
+		Username:   req.Username,
+		Header:     req.Header,
+		Algorithm:  req.Algorithm,
+		AllowedIPs: req.AllowedIPs,
+	}
+	if settings.Mode == verification.ModeNone {
+		settings.Store(instance.CustomSettings)
+		return nil
+	}
+
+	current, _ := verification.FromCustomSettings(instance.CustomSettings)
+	if err := settings.SetSecret(api.encryptor, req.Secret, current); err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyCorrelation переносит настройки группировки сообщений в CustomSettings.
+// Пустое выражение ключа отключает группировку.
+func applyCorrelation(instance *domain.IntegrationInstance, settings *correlation.Settings) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	if settings.Key != "" {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyAlertmanager переносит настройки режима Alertmanager в CustomSettings.
+// enabled: false выключает режим.
+func applyAlertmanager(instance *domain.IntegrationInstance, settings *alertmanager.Settings) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	if settings.Enabled {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyDedup переносит настройки дедупликации вебхуков в CustomSettings.
+// enabled: false выключает дедупликацию.
+func applyDedup(instance *domain.IntegrationInstance, settings *dedup.Settings) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	if settings.Enabled {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyFilters переносит правила фильтрации в CustomSettings.
+// Пустой список правил с действием по умолчанию send удаляет фильтрацию.
+func applyFilters(instance *domain.IntegrationInstance, settings *filter.Settings) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyRoutes переносит маршруты в CustomSettings. Пустой список удаляет маршрутизацию.
+func applyRoutes(instance *domain.IntegrationInstance, settings *routing.Settings) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyDirect переносит настройки личных сообщений в CustomSettings.
+// enabled: false выключает режим.
+func applyDirect(instance *domain.IntegrationInstance, settings *recipients.Settings) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	if settings.Enabled {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// save сохраняет экземпляр и возвращает его актуальное состояние
+func (api *InstanceAPI) save(c echo.Context, instance *domain.IntegrationInstance) error {
+	if err := api.repo.UpdateInstance(c.Request().Context(), instance); err != nil {
+		log.Error().Err(err).Str("id", instance.ID).Msg("Failed to update instance")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update instance"})
+	}
+
+	updated, err := api.repo.GetInstanceWithTemplate(c.Request().Context(), instance.ID, instance.UserID)
+	if err != nil {
+		return c.JSON(http.StatusOK, map[string]string{"message": "updated"})
+	}
+	return c.JSON(http.StatusOK, redactInstance(updated))
+}
+
+// Deliveries возвращает историю доставок экземпляра
+// GET /api/v1/instances/:id/deliveries?limit=50&offset=0&kind=attempt&status=failed
+func (api *InstanceAPI) Deliveries(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.QueryParam("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter := domain.DeliveryLogFilter{
+		Kind:   c.QueryParam("kind"),
+		Status: c.QueryParam("status"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if _, err := api.repo.GetInstanceByID(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+
+	logs, total, err := api.repo.GetDeliveryLogs(c.Request().Context(), id, userID, filter)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to load delivery logs")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load delivery logs"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":   logs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// RedeliverRequest - параметры повторной отправки недоставленного сообщения
+type RedeliverRequest struct {
+	Message string `json:"message"`
+	ChatID  string `json:"chat_id"`
+}
+
+// DeadLetters возвращает недоставленные сообщения экземпляра
+// GET /api/v1/instances/:id/dead-letters
+func (api *InstanceAPI) DeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	if _, err := api.repo.GetInstanceByID(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+
+	letters, err := api.repo.ListDeadLetters(c.Request().Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to load dead letters")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load dead letters"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  letters,
+		"total": len(letters),
+	})
+}
+
+// RedeliverDeadLetter ставит недоставленное сообщение в очередь повторно, опционально с новым текстом или чатом
+// POST /api/v1/instances/:id/dead-letters/:letter_id/redeliver
+func (api *InstanceAPI) RedeliverDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	letterID, err := strconv.ParseInt(c.Param("letter_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid letter id"})
+	}
+
+	var req RedeliverRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if _, err := api.repo.GetInstanceByID(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+
+	if err := api.repo.RedeliverDeadLetter(c.Request().Context(), id, letterID, req.Message, req.ChatID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "dead letter not found"})
+		}
+		log.Error().Err(err).Str("instance_id", id).Int64("outbox_id", letterID).Msg("Failed to redeliver dead letter")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to redeliver dead letter"})
+	}
+
+	log.Info().Str("instance_id", id).Int64("outbox_id", letterID).Msg("Dead letter queued for redelivery")
+	return c.JSON(http.StatusOK, map[string]string{"message": "queued for redelivery"})
+}
+
+// RedeliverAllDeadLetters ставит в очередь все недоставленные сообщения экземпляра
+// POST /api/v1/instances/:id/dead-letters/redeliver
+func (api *InstanceAPI) RedeliverAllDeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	var req RedeliverRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if _, err := api.repo.GetInstanceByID(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+
+	count, err := api.repo.RedeliverAllDeadLetters(c.Request().Context(), id, req.ChatID)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to redeliver dead letters")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to redeliver dead letters"})
+	}
+
+	log.Info().Str("instance_id", id).Int64("count", count).Msg("Dead letters queued for redelivery")
+	return c.JSON(http.StatusOK, map[string]interface{}{"queued": count})
+}
+
+// DiscardDeadLetter удаляет недоставленное сообщение
+// DELETE /api/v1/instances/:id/dead-letters/:letter_id
+func (api *InstanceAPI) DiscardDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	letterID, err := strconv.ParseInt(c.Param("letter_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid letter id"})
+	}
+
+	if _, err := api.repo.GetInstanceByID(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+
+	if err := api.repo.DiscardDeadLetter(c.Request().Context(), id, letterID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "dead letter not found"})
+		}
+		log.Error().Err(err).Str("instance_id", id).Int64("outbox_id", letterID).Msg("Failed to discard dead letter")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to discard dead letter"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DiscardAllDeadLetters удаляет все недоставленные сообщения экземпляра
+// DELETE /api/v1/instances/:id/dead-letters
+func (api *InstanceAPI) DiscardAllDeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	userID := c.Get("user_id").(string)
+
+	if _, err := api.repo.GetInstanceByID(c.Request().Context(), id, userID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "instance not found"})
+	}
+
+	count, err := api.repo.DiscardAllDeadLetters(c.Request().Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to discard dead letters")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to discard dead letters"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"discarded": count})
+}
@@ -0,0 +1,88 @@
+// Путь: internal/transport/api/template_bundles.go
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/bundle"
+)
+
+// Export выгружает шаблоны пакетом с примерами данных и тестами.
+// Без id выгружаются все доступные пользователю шаблоны: свои и публичные.
+// GET /api/v1/templates/export?id=<ID>&id=<ID>&format=json|yaml
+func (api *TemplateAPI) Export(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var list []*domain.Template
+	if ids := c.QueryParams()["id"]; len(ids) > 0 {
+		for _, id := range ids {
+			template, err := api.repo.GetTemplateByID(c.Request().Context(), id)
+			if err != nil || !canViewTemplate(c, template) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("template %s not found", id)})
+			}
+			list = append(list, template)
+		}
+	} else {
+		var err error
+		list, err = api.repo.ListTemplates(c.Request().Context(), userID, true)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list templates")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list templates"})
+		}
+	}
+
+	b, err := bundle.Export(c.Request().Context(), api.repo, list)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to export templates")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to export templates"})
+	}
+
+	format := bundle.Format(c.QueryParam("format"))
+	if format == bundle.FormatJSON {
+		return c.JSON(http.StatusOK, b)
+	}
+	data, err := bundle.Marshal(b, format)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode template bundle")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to export templates"})
+	}
+	filename := fmt.Sprintf("templates-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Blob(http.StatusOK, "application/yaml; charset=utf-8", data)
+}
+
+// Import создает шаблоны из пакета JSON или YAML в теле запроса.
+// Конфликт определяется по названию: on_conflict=skip (по умолчанию), overwrite или rename.
+// Публичные шаблоны и перезапись чужих доступны только администратору.
+// POST /api/v1/templates/import?on_conflict=skip
+func (api *TemplateAPI) Import(c echo.Context) error {
+	onConflict := c.QueryParam("on_conflict")
+	if !bundle.ValidConflict(onConflict) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "on_conflict must be skip, overwrite or rename"})
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, bundle.MaxSize))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+	}
+	b, err := bundle.Unmarshal(data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	report := bundle.Import(c.Request().Context(), api.repo, b, bundle.Options{
+		OnConflict: onConflict,
+		UserID:     c.Get("user_id").(string),
+		Admin:      isAdmin(c),
+	})
+
+	log.Info().Int("templates", len(b.Templates)).Int("failed", report.Count(bundle.ActionFailed)).Msg("Templates imported via API")
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,186 @@
+// Путь: internal/transport/api/template_samples.go
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/samples"
+)
+
+// SampleRequest - пример данных шаблона.
+// Payload - JSON-значение или строка (форма, XML, текст); ContentType задаёт, как разбирать строку.
+type SampleRequest struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	ContentType string          `json:"content_type"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// CaptureSampleRequest - имя и описание примера, сохраняемого из последнего вебхука
+type CaptureSampleRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// loadSamples загружает примеры данных шаблона в template.Samples
+func loadSamples(ctx context.Context, repo _interface.IntegrationRepository, template *domain.Template) error {
+	if template == nil {
+		return nil
+	}
+	list, err := repo.ListTemplateSamples(ctx, template.ID)
+	if err != nil {
+		return err
+	}
+	template.Samples = list
+	return nil
+}
+
+// sampleError возвращает ответ для ошибки создания примера
+func sampleError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, samples.ErrInvalidName), errors.Is(err, samples.ErrEmptyPayload):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, samples.ErrNoLastWebhook):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save sample"})
+	}
+}
+
+// ListSamples возвращает примеры данных шаблона
+// GET /api/v1/templates/:id/samples
+func (api *TemplateAPI) ListSamples(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  template.Samples,
+		"total": len(template.Samples),
+	})
+}
+
+// SaveSample создает пример данных шаблона или заменяет пример с тем же именем.
+// Доступно автору шаблона и администратору.
+// POST /api/v1/templates/:id/samples
+func (api *TemplateAPI) SaveSample(c echo.Context) error {
+	var req SampleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if !canEditTemplate(c, template) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+
+	var body []byte
+	if len(req.Payload) > 0 {
+		body = []byte(rawPayload(req.Payload))
+	}
+	sample, err := samples.New(template.ID, req.Name, req.Description, req.ContentType, body)
+	if err != nil {
+		return sampleError(c, err)
+	}
+
+	if err := api.repo.SaveTemplateSample(c.Request().Context(), sample); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to save template sample")
+		return sampleError(c, err)
+	}
+
+	log.Info().Str("id", template.ID).Str("sample", sample.Name).Msg("Template sample saved via API")
+	return c.JSON(http.StatusCreated, sample)
+}
+
+// GetSample возвращает пример данных шаблона
+// GET /api/v1/templates/:id/samples/:sample_id
+func (api *TemplateAPI) GetSample(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	sample, err := api.repo.GetTemplateSample(c.Request().Context(), template.ID, c.Param("sample_id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "sample not found"})
+	}
+
+	return c.JSON(http.StatusOK, sample)
+}
+
+// DeleteSample удаляет пример данных шаблона. Доступно автору шаблона и администратору.
+// DELETE /api/v1/templates/:id/samples/:sample_id
+func (api *TemplateAPI) DeleteSample(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if !canEditTemplate(c, template) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+
+	err = api.repo.DeleteTemplateSample(c.Request().Context(), template.ID, c.Param("sample_id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "sample not found"})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to delete template sample")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete sample"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CaptureSample сохраняет последний вебхук экземпляра как пример данных его шаблона.
+// Доступно автору шаблона и администратору.
+// POST /api/v1/instances/:id/last-webhook/sample
+func (api *InstanceAPI) CaptureSample(c echo.Context) error {
+	instance, err := api.loadInstance(c)
+	if instance == nil {
+		return err
+	}
+
+	var req CaptureSampleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), instance.TemplateID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if !canEditTemplate(c, template) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+
+	sample, err := samples.FromLastWebhook(instance, req.Name, req.Description)
+	if err != nil {
+		return sampleError(c, err)
+	}
+
+	if err := api.repo.SaveTemplateSample(c.Request().Context(), sample); err != nil {
+		log.Error().Err(err).Str("instance_id", instance.ID).Msg("Failed to save template sample")
+		return sampleError(c, err)
+	}
+
+	log.Info().Str("instance_id", instance.ID).Str("template_id", template.ID).Str("sample", sample.Name).Msg("Last webhook saved as template sample")
+	return c.JSON(http.StatusCreated, sample)
+}
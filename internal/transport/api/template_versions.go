@@ -0,0 +1,163 @@
+// Путь: internal/transport/api/template_versions.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/service/diff"
+	"yandex-messenger-bridge/internal/service/templatetest"
+)
+
+// DiffResponse - построчное сравнение текста двух версий шаблона
+type DiffResponse struct {
+	From    int         `json:"from"`
+	To      int         `json:"to"`
+	Changed bool        `json:"changed"`
+	Lines   []diff.Line `json:"lines"`
+	Unified string      `json:"unified"`
+}
+
+// ListVersions возвращает историю версий шаблона, начиная с последней.
+// Автор версии виден только тем, кто может редактировать шаблон.
+// GET /api/v1/templates/:id/versions
+func (api *TemplateAPI) ListVersions(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	versions, err := api.repo.ListTemplateVersions(c.Request().Context(), template.ID)
+	if err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template versions")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load versions"})
+	}
+	if !canEditTemplate(c, template) {
+		for _, version := range versions {
+			version.AuthorID, version.AuthorEmail = nil, nil
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  versions,
+		"total": len(versions),
+	})
+}
+
+// GetVersion возвращает снимок версии шаблона
+// GET /api/v1/templates/:id/versions/:version
+func (api *TemplateAPI) GetVersion(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid version"})
+	}
+	version, err := api.repo.GetTemplateVersion(c.Request().Context(), template.ID, number)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "version not found"})
+	}
+	if !canEditTemplate(c, template) {
+		version.AuthorID, version.AuthorEmail = nil, nil
+	}
+
+	return c.JSON(http.StatusOK, version)
+}
+
+// Diff сравнивает текст двух версий шаблона.
+// По умолчанию to - текущая версия, from - предыдущая (для v1 - она же).
+// GET /api/v1/templates/:id/diff?from=&to=
+func (api *TemplateAPI) Diff(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+
+	to := template.Version
+	if value := c.QueryParam("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid version"})
+		}
+	}
+	from := to - 1
+	if from < 1 {
+		// У первой версии нет предыдущей: сравниваем её с собой, diff пустой
+		from = 1
+	}
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = strconv.Atoi(value); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid version"})
+		}
+	}
+
+	fromVersion, err := api.repo.GetTemplateVersion(c.Request().Context(), template.ID, from)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("version %d not found", from)})
+	}
+	toVersion, err := api.repo.GetTemplateVersion(c.Request().Context(), template.ID, to)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("version %d not found", to)})
+	}
+
+	lines := diff.Lines(fromVersion.TemplateText, toVersion.TemplateText)
+	return c.JSON(http.StatusOK, DiffResponse{
+		From:    from,
+		To:      to,
+		Changed: diff.Changed(lines),
+		Lines:   lines,
+		Unified: diff.Unified(lines),
+	})
+}
+
+// Rollback возвращает шаблон к сохранённой версии. Откат записывается новой версией.
+// Доступно автору шаблона и администратору.
+// POST /api/v1/templates/:id/versions/:version/rollback
+func (api *TemplateAPI) Rollback(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if !canEditTemplate(c, template) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid version"})
+	}
+	version, err := api.repo.GetTemplateVersion(c.Request().Context(), template.ID, number)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "version not found"})
+	}
+
+	template.Restore(version)
+	template.ChangeAuthor = c.Get("user_id").(string)
+	template.ChangeNote = fmt.Sprintf("Откат к версии v%d", version.Version)
+
+	// Откат - такое же сохранение: публичный шаблон с непроходящими тестами сохранить нельзя
+	if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+	if report, err := templatetest.Check(template); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": err.Error(),
+			"tests": report,
+		})
+	}
+
+	if err := api.repo.UpdateTemplate(c.Request().Context(), template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to rollback template")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to rollback template"})
+	}
+
+	log.Info().Str("id", template.ID).Int("version", version.Version).Int("new_version", template.Version).Msg("Template rolled back via API")
+	return c.JSON(http.StatusOK, template)
+}
@@ -0,0 +1,286 @@
+// Путь: internal/transport/api/templates.go
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/preview"
+	"yandex-messenger-bridge/internal/service/templatetest"
+)
+
+// TemplateAPI - JSON API для шаблонов
+type TemplateAPI struct {
+	repo _interface.IntegrationRepository
+}
+
+// TemplateRequest - параметры создания и обновления шаблона.
+// Tests == nil при обновлении оставляет тесты шаблона без изменений.
+// Note - комментарий к новой версии шаблона в истории.
+type TemplateRequest struct {
+	Name         string                `json:"name"`
+	Description  string                `json:"description"`
+	Icon         string                `json:"icon"`
+	TemplateText string                `json:"template_text"`
+	IsPublic     bool                  `json:"is_public"`
+	Tests        []domain.TemplateTest `json:"tests"`
+	Note         string                `json:"note,omitempty"`
+}
+
+// PreviewRequest - параметры предпросмотра шаблона.
+// Sample - имя примера шаблона для source=sample (по умолчанию первый).
+// Payload - данные для source=payload: JSON-значение или строка (форма, XML, текст).
+type PreviewRequest struct {
+	TemplateText string          `json:"template_text"`
+	TemplateID   string          `json:"template_id,omitempty"`
+	Source       string          `json:"source"`
+	Sample       string          `json:"sample,omitempty"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+}
+
+// pasted возвращает вставленные данные: строка - как есть, остальное - исходный JSON
+func (r *PreviewRequest) pasted() string {
+	return rawPayload(r.Payload)
+}
+
+// rawPayload возвращает данные из запроса: строка - как есть, остальное - исходный JSON
+func rawPayload(payload json.RawMessage) string {
+	var text string
+	if json.Unmarshal(payload, &text) == nil {
+		return text
+	}
+	return string(payload)
+}
+
+func NewTemplateAPI(repo _interface.IntegrationRepository) *TemplateAPI {
+	return &TemplateAPI{
+		repo: repo,
+	}
+}
+
+// List возвращает шаблоны пользователя и публичные шаблоны
+// GET /api/v1/templates
+func (api *TemplateAPI) List(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	templates, err := api.repo.ListTemplates(c.Request().Context(), userID, true)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list templates")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list templates"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  templates,
+		"total": len(templates),
+	})
+}
+
+// Get возвращает шаблон вместе с примерами данных, если он публичный или принадлежит пользователю
+// GET /api/v1/templates/:id
+func (api *TemplateAPI) Get(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+
+	return c.JSON(http.StatusOK, template)
+}
+
+// Create создает шаблон. Публичные шаблоны может создавать только администратор.
+// POST /api/v1/templates
+func (api *TemplateAPI) Create(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req TemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if req.Name == "" || req.TemplateText == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name and template_text are required"})
+	}
+	if req.IsPublic && !isAdmin(c) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "only admins can create public templates"})
+	}
+	if err := templatetest.Validate(req.Tests); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	template := &domain.Template{
+		Name:         req.Name,
+		Description:  req.Description,
+		Icon:         req.Icon,
+		TemplateText: req.TemplateText,
+		IsPublic:     req.IsPublic,
+		CreatedBy:    sql.NullString{String: userID, Valid: true},
+		Tests:        req.Tests,
+		ChangeAuthor: userID,
+		ChangeNote:   req.Note,
+	}
+
+	if report, err := templatetest.Check(template); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": err.Error(),
+			"tests": report,
+		})
+	}
+
+	if err := api.repo.CreateTemplate(c.Request().Context(), template); err != nil {
+		log.Error().Err(err).Msg("Failed to create template")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create template"})
+	}
+
+	log.Info().Str("id", template.ID).Str("name", template.Name).Msg("Template created via API")
+	return c.JSON(http.StatusCreated, template)
+}
+
+// Update обновляет шаблон. Доступно автору шаблона и администратору.
+// PUT /api/v1/templates/:id
+func (api *TemplateAPI) Update(c echo.Context) error {
+	var req TemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if req.Name == "" || req.TemplateText == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "name and template_text are required"})
+	}
+
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if !canEditTemplate(c, template) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+	if req.IsPublic && !template.IsPublic && !isAdmin(c) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "only admins can publish templates"})
+	}
+	if err := templatetest.Validate(req.Tests); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+	template.Icon = req.Icon
+	template.TemplateText = req.TemplateText
+	template.IsPublic = req.IsPublic
+	if req.Tests != nil {
+		template.Tests = req.Tests
+	}
+	template.ChangeAuthor = c.Get("user_id").(string)
+	template.ChangeNote = req.Note
+
+	if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+	if report, err := templatetest.Check(template); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": err.Error(),
+			"tests": report,
+		})
+	}
+
+	if err := api.repo.UpdateTemplate(c.Request().Context(), template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to update template")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update template"})
+	}
+
+	log.Info().Str("id", template.ID).Msg("Template updated via API")
+	return c.JSON(http.StatusOK, template)
+}
+
+// Delete удаляет шаблон. Доступно автору шаблона и администратору.
+// DELETE /api/v1/templates/:id
+func (api *TemplateAPI) Delete(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if !canEditTemplate(c, template) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "access denied"})
+	}
+
+	if err := api.repo.DeleteTemplate(c.Request().Context(), template.ID); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to delete template")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete template"})
+	}
+
+	log.Info().Str("id", template.ID).Msg("Template deleted via API")
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Preview рендерит шаблон на примере данных шаблона template_id или на переданных данных
+// POST /api/v1/templates/preview
+func (api *TemplateAPI) Preview(c echo.Context) error {
+	var req PreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	var template *domain.Template
+	if req.TemplateID != "" {
+		found, err := api.repo.GetTemplateByID(c.Request().Context(), req.TemplateID)
+		if err != nil || !canViewTemplate(c, found) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+		}
+		template = found
+		if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+			log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+		}
+	}
+
+	text := req.TemplateText
+	if text == "" && template != nil {
+		text = template.TemplateText
+	}
+	if text == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "template_text is required"})
+	}
+
+	return c.JSON(http.StatusOK, preview.Preview(text, req.Source, req.Sample, req.pasted(), nil, template))
+}
+
+// RunTests выполняет сохранённые тесты шаблона
+// POST /api/v1/templates/:id/tests
+func (api *TemplateAPI) RunTests(c echo.Context) error {
+	template, err := api.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || !canViewTemplate(c, template) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "template not found"})
+	}
+	if err := loadSamples(c.Request().Context(), api.repo, template); err != nil {
+		log.Error().Err(err).Str("id", template.ID).Msg("Failed to load template samples")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load samples"})
+	}
+
+	return c.JSON(http.StatusOK, templatetest.Run(template))
+}
+
+// isAdmin проверяет роль текущего пользователя
+func isAdmin(c echo.Context) bool {
+	role, _ := c.Get("user_role").(string)
+	return role == "admin"
+}
+
+// canViewTemplate - шаблон публичный, принадлежит пользователю или пользователь администратор
+func canViewTemplate(c echo.Context, template *domain.Template) bool {
+	return template.IsPublic || canEditTemplate(c, template)
+}
+
+// canEditTemplate - шаблон принадлежит пользователю или пользователь администратор
+func canEditTemplate(c echo.Context, template *domain.Template) bool {
+	userID := c.Get("user_id").(string)
+	return isAdmin(c) || (template.CreatedBy.Valid && template.CreatedBy.String == userID)
+}
@@ -3,19 +3,28 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
+
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/apikey"
 )
 
+// apiKeyTouchInterval - как часто обновлять last_used_at ключа
+const apiKeyTouchInterval = time.Minute
+
 type AuthMiddleware struct {
 	jwtSecret []byte
+	repo      _interface.IntegrationRepository
 }
 
-func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
+func NewAuthMiddleware(jwtSecret string, repo _interface.IntegrationRepository) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtSecret: []byte(jwtSecret),
+		repo:      repo,
 	}
 }
 
@@ -35,8 +44,16 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			}
 		}
 
-		// Затем проверяем заголовок Authorization
+		// API-ключ в X-API-Key
+		if key := c.Request().Header.Get("X-API-Key"); key != "" {
+			return m.authenticateAPIKey(c, key, next)
+		}
+
+		// Затем проверяем заголовок Authorization: JWT или API-ключ
 		token := extractToken(c.Request())
+		if apikey.IsKey(token) {
+			return m.authenticateAPIKey(c, token, next)
+		}
 		if token != "" {
 			claims, err := m.validateJWT(token)
 			if err == nil {
@@ -50,6 +67,50 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey проверяет персональный API-ключ. Ключ действует от имени владельца;
+// ключ только для чтения допускает лишь GET и HEAD запросы.
+func (m *AuthMiddleware) authenticateAPIKey(c echo.Context, key string, next echo.HandlerFunc) error {
+	ctx := c.Request().Context()
+
+	apiKey, err := m.repo.FindAPIKeyByHash(ctx, apikey.Hash(key))
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid api key"})
+	}
+
+	now := time.Now()
+	if apiKey.IsExpired(now) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "api key expired"})
+	}
+
+	user, err := m.repo.FindUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid api key"})
+	}
+
+	// Обработчик выбран по методу маршрута, а X-HTTP-Method-Override меняет только метод запроса:
+	// для ключа только для чтения оба должны быть GET или HEAD
+	if apiKey.ReadOnly && (!isReadMethod(routeMethod(c)) || !isReadMethod(c.Request().Method)) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "api key is read-only"})
+	}
+
+	// Не пишем в БД на каждый запрос
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := m.repo.UpdateAPIKeyLastUsed(ctx, apiKey.ID); err != nil {
+			log.Error().Err(err).Str("key_id", apiKey.ID).Msg("Failed to update api key last used time")
+		}
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("user_role", user.Role)
+	c.Set("api_key_id", apiKey.ID)
+	return next(c)
+}
+
+// isReadMethod сообщает, что метод не изменяет данные
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
 // RequireTempAuth проверяет временный токен (для смены пароля)
 func (m *AuthMiddleware) RequireTempAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/apikey"
+)
+
+const testKey = apikey.Prefix + "test-key"
+
+// keyRepo знает один API-ключ testKey и его владельца
+type keyRepo struct {
+	_interface.IntegrationRepository
+	readOnly bool
+}
+
+func (r *keyRepo) FindAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	if hash != apikey.Hash(testKey) {
+		return nil, errors.New("not found")
+	}
+	return &domain.APIKey{ID: "key-1", UserID: "user-1", ReadOnly: r.readOnly}, nil
+}
+
+func (r *keyRepo) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return &domain.User{ID: id, Role: "user"}, nil
+}
+
+func (r *keyRepo) UpdateAPIKeyLastUsed(ctx context.Context, id string) error {
+	return nil
+}
+
+// newTestServer собирает сервер с тем же порядком middleware, что и cmd/integrator
+func newTestServer(readOnly bool, called *string) *echo.Echo {
+	e := echo.New()
+	e.Use(MethodOverride())
+
+	auth := NewAuthMiddleware("secret", &keyRepo{readOnly: readOnly})
+	g := e.Group("/api", auth.RequireAuth)
+	g.GET("/instances/:id", func(c echo.Context) error {
+		*called = "get"
+		return c.NoContent(http.StatusOK)
+	})
+	g.POST("/instances/:id/rotate-token", func(c echo.Context) error {
+		*called = "rotate"
+		return c.NoContent(http.StatusOK)
+	})
+	return e
+}
+
+func TestReadOnlyKey(t *testing.T) {
+	cases := []struct {
+		name     string
+		readOnly bool
+		method   string
+		path     string
+		override string
+		status   int
+		called   string
+	}{
+		{name: "read", readOnly: true, method: http.MethodGet, path: "/api/instances/1", status: http.StatusOK, called: "get"},
+		{name: "write", readOnly: true, method: http.MethodPost, path: "/api/instances/1/rotate-token", status: http.StatusForbidden},
+		{name: "write with override", readOnly: true, method: http.MethodPost, path: "/api/instances/1/rotate-token", override: http.MethodGet, status: http.StatusForbidden},
+		{name: "full key write", readOnly: false, method: http.MethodPost, path: "/api/instances/1/rotate-token", status: http.StatusOK, called: "rotate"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var called string
+			e := newTestServer(tc.readOnly, &called)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("X-API-Key", testKey)
+			if tc.override != "" {
+				req.Header.Set("X-HTTP-Method-Override", tc.override)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tc.status, rec.Body.String())
+			}
+			if called != tc.called {
+				t.Fatalf("handler = %q, want %q", called, tc.called)
+			}
+		})
+	}
+}
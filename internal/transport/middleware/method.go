@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+)
+
+// routeMethodKey - ключ контекста с методом, по которому был выбран маршрут
+const routeMethodKey = "route_method"
+
+// MethodOverride подменяет метод запроса из X-HTTP-Method-Override или поля формы _method.
+// Middleware регистрируется через e.Use и работает уже после маршрутизации: обработчик выбран
+// по исходному методу, поэтому он сохраняется в контексте для проверки прав (см. routeMethod).
+func MethodOverride() echo.MiddlewareFunc {
+	override := echomw.MethodOverrideWithConfig(echomw.MethodOverrideConfig{
+		Getter: func(c echo.Context) string {
+			if method := c.Request().Header.Get("X-HTTP-Method-Override"); method != "" {
+				return method
+			}
+			return c.FormValue("_method")
+		},
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		overridden := override(next)
+		return func(c echo.Context) error {
+			c.Set(routeMethodKey, c.Request().Method)
+			return overridden(c)
+		}
+	}
+}
+
+// routeMethod возвращает метод, по которому выбран маршрут, а без MethodOverride - метод запроса
+func routeMethod(c echo.Context) string {
+	if method, ok := c.Get(routeMethodKey).(string); ok {
+		return method
+	}
+	return c.Request().Method
+}
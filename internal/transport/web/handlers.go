@@ -3,11 +3,17 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	//"html/template"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	//"math"
 
@@ -16,7 +22,23 @@ import (
 
 	"yandex-messenger-bridge/internal/domain"
 	repoInterface "yandex-messenger-bridge/internal/repository/interface"
+	"yandex-messenger-bridge/internal/service/alertmanager"
+	"yandex-messenger-bridge/internal/service/apikey"
+	"yandex-messenger-bridge/internal/service/bundle"
+	"yandex-messenger-bridge/internal/service/correlation"
+	"yandex-messenger-bridge/internal/service/dedup"
+	"yandex-messenger-bridge/internal/service/diff"
 	"yandex-messenger-bridge/internal/service/encryption"
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/fork"
+	"yandex-messenger-bridge/internal/service/payload"
+	"yandex-messenger-bridge/internal/service/preview"
+	"yandex-messenger-bridge/internal/service/recipients"
+	"yandex-messenger-bridge/internal/service/routing"
+	"yandex-messenger-bridge/internal/service/samples"
+	"yandex-messenger-bridge/internal/service/templatetest"
+	"yandex-messenger-bridge/internal/service/verification"
+	"yandex-messenger-bridge/internal/service/webhook"
 	"yandex-messenger-bridge/internal/web/templates/pages"
 	"yandex-messenger-bridge/internal/yandex"
 )
@@ -25,13 +47,15 @@ import (
 type Handler struct {
 	repo      repoInterface.IntegrationRepository
 	encryptor *encryption.Encryptor
+	webhooks  *webhook.Handler
 }
 
 // NewHandler создает новый обработчик
-func NewHandler(repo repoInterface.IntegrationRepository, encryptor *encryption.Encryptor) *Handler {
+func NewHandler(repo repoInterface.IntegrationRepository, encryptor *encryption.Encryptor, webhooks *webhook.Handler) *Handler {
 	return &Handler{
 		repo:      repo,
 		encryptor: encryptor,
+		webhooks:  webhooks,
 	}
 }
 
@@ -117,6 +141,19 @@ func getUserIDFromContext(c echo.Context) string {
 	return userID.(string)
 }
 
+// loadSamples загружает примеры данных шаблона для предпросмотра; без примеров предпросмотр всё равно работает
+func (h *Handler) loadSamples(ctx context.Context, template *domain.Template) {
+	if template == nil {
+		return
+	}
+	samples, err := h.repo.ListTemplateSamples(ctx, template.ID)
+	if err != nil {
+		log.Error().Err(err).Str("template_id", template.ID).Msg("Failed to load template samples")
+		return
+	}
+	template.Samples = samples
+}
+
 func getBaseURL(c echo.Context) string {
 	scheme := "http"
 	if c.Request().TLS != nil {
@@ -168,6 +205,7 @@ func (h *Handler) TemplateEditPage(c echo.Context) error {
 		if err != nil {
 			return c.String(http.StatusNotFound, "Template not found")
 		}
+		h.loadSamples(c.Request().Context(), template)
 	}
 
 	return pages.TemplateEditPage(template, user).Render(c.Request().Context(), c.Response().Writer)
@@ -193,6 +231,11 @@ func (h *Handler) CreateTemplate(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Name and template text are required")
 	}
 
+	tests, err := templatetest.Parse(c.FormValue("tests"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
 	if id != "" {
 		// Обновление существующего шаблона
 		template, err := h.repo.GetTemplateByID(c.Request().Context(), id)
@@ -205,6 +248,13 @@ func (h *Handler) CreateTemplate(c echo.Context) error {
 		template.Description = description
 		template.TemplateText = templateText
 		template.IsPublic = isPublic
+		template.Tests = tests
+		template.ChangeAuthor = userID
+		template.ChangeNote = c.FormValue("change_note")
+
+		if err := h.checkTemplateTests(c.Request().Context(), template); err != nil {
+			return c.String(http.StatusUnprocessableEntity, err.Error())
+		}
 
 		if err := h.repo.UpdateTemplate(c.Request().Context(), template); err != nil {
 			log.Error().Err(err).Msg("Failed to update template")
@@ -221,6 +271,13 @@ func (h *Handler) CreateTemplate(c echo.Context) error {
 			IsPublic:      isPublic,
 			CreatedBy:     sql.NullString{String: userID, Valid: userID != ""},
 			SamplePayload: nil,
+			Tests:         tests,
+			ChangeAuthor:  userID,
+			ChangeNote:    c.FormValue("change_note"),
+		}
+
+		if err := h.checkTemplateTests(c.Request().Context(), template); err != nil {
+			return c.String(http.StatusUnprocessableEntity, err.Error())
 		}
 
 		if err := h.repo.CreateTemplate(c.Request().Context(), template); err != nil {
@@ -233,6 +290,55 @@ func (h *Handler) CreateTemplate(c echo.Context) error {
 	return c.Redirect(http.StatusSeeOther, "/admin/templates")
 }
 
+// checkTemplateTests выполняет тесты шаблона перед сохранением.
+// Ошибка означает, что публичный шаблон не прошёл тесты и сохранять его нельзя.
+func (h *Handler) checkTemplateTests(ctx context.Context, template *domain.Template) error {
+	if len(template.Tests) == 0 {
+		return nil
+	}
+	if template.ID != "" {
+		h.loadSamples(ctx, template)
+	}
+
+	report, err := templatetest.Check(template)
+	if err != nil {
+		log.Warn().Str("template_id", template.ID).Str("tests", report.Summary()).Msg("Public template rejected by its tests")
+		return fmt.Errorf("%w: %s", err, report.Summary())
+	}
+	if !report.OK() {
+		log.Warn().Str("template_id", template.ID).Str("tests", report.Summary()).Msg("Private template saved with failing tests")
+	}
+	return nil
+}
+
+// RunTemplateTests выполняет тесты из редактора шаблона без сохранения (HTMX)
+func (h *Handler) RunTemplateTests(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	// Проверяем права админа
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	template := &domain.Template{Name: c.FormValue("name")}
+	if id := c.FormValue("id"); id != "" {
+		if found, err := h.repo.GetTemplateByID(c.Request().Context(), id); err == nil {
+			template = found
+			h.loadSamples(c.Request().Context(), template)
+		}
+	}
+	template.TemplateText = c.FormValue("template_text")
+
+	tests, err := templatetest.Parse(c.FormValue("tests"))
+	if err != nil {
+		return pages.TemplateTestReport(nil, err.Error()).Render(c.Request().Context(), c.Response().Writer)
+	}
+	template.Tests = tests
+
+	return pages.TemplateTestReport(templatetest.Run(template), "").Render(c.Request().Context(), c.Response().Writer)
+}
+
 // DeleteTemplate удаляет шаблон
 func (h *Handler) DeleteTemplate(c echo.Context) error {
 	userID := getUserIDFromContext(c)
@@ -262,6 +368,347 @@ func (h *Handler) DeleteTemplate(c echo.Context) error {
 	return pages.TemplatesAdminTable(templates).Render(c.Request().Context(), c.Response().Writer)
 }
 
+// PreviewTemplate рендерит шаблон из редактора на примере данных шаблона или вставленных данных (HTMX)
+func (h *Handler) PreviewTemplate(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	// Проверяем права админа
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	var template *domain.Template
+	if id := c.FormValue("id"); id != "" {
+		if found, err := h.repo.GetTemplateByID(c.Request().Context(), id); err == nil {
+			template = found
+			h.loadSamples(c.Request().Context(), template)
+		}
+	}
+
+	result := preview.Preview(c.FormValue("template_text"), c.FormValue("preview_source"), c.FormValue("preview_sample"), c.FormValue("preview_payload"), nil, template)
+	return pages.TemplatePreviewResult(result).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// SaveTemplateSample сохраняет пример данных шаблона из формы или загруженного файла (HTMX)
+func (h *Handler) SaveTemplateSample(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	// Проверяем права админа
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "Template not found")
+	}
+
+	// Файл имеет приоритет над текстом; его тип используется, если Content-Type не указан явно
+	body := []byte(c.FormValue("payload"))
+	contentType := strings.TrimSpace(c.FormValue("content_type"))
+	if file, err := c.FormFile("payload_file"); err == nil {
+		src, err := file.Open()
+		if err == nil {
+			body, err = io.ReadAll(io.LimitReader(src, maxSampleSize))
+			src.Close()
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to read sample file")
+			return h.renderTemplateSamples(c, template, "Не удалось прочитать файл")
+		}
+		if contentType == "" {
+			contentType = file.Header.Get("Content-Type")
+		}
+	}
+
+	sample, err := samples.New(template.ID, strings.TrimSpace(c.FormValue("name")), c.FormValue("description"), contentType, body)
+	if err != nil {
+		return h.renderTemplateSamples(c, template, sampleErrorText(err))
+	}
+
+	if err := h.repo.SaveTemplateSample(c.Request().Context(), sample); err != nil {
+		log.Error().Err(err).Str("template_id", template.ID).Msg("Failed to save template sample")
+		return h.renderTemplateSamples(c, template, "Не удалось сохранить пример")
+	}
+
+	log.Info().Str("template_id", template.ID).Str("sample", sample.Name).Msg("Template sample saved")
+	return h.renderTemplateSamples(c, template, "")
+}
+
+// DeleteTemplateSample удаляет пример данных шаблона (HTMX)
+func (h *Handler) DeleteTemplateSample(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	// Проверяем права админа
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "Template not found")
+	}
+
+	message := ""
+	if err := h.repo.DeleteTemplateSample(c.Request().Context(), template.ID, c.Param("sample_id")); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).Str("template_id", template.ID).Msg("Failed to delete template sample")
+		message = "Не удалось удалить пример"
+	}
+
+	return h.renderTemplateSamples(c, template, message)
+}
+
+// renderTemplateSamples перерисовывает список примеров шаблона с сообщением об ошибке
+func (h *Handler) renderTemplateSamples(c echo.Context, template *domain.Template, message string) error {
+	h.loadSamples(c.Request().Context(), template)
+	return pages.TemplateSamples(template, message).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// maxSampleSize - ограничение размера загружаемого примера
+const maxSampleSize = 1 << 20
+
+// sampleErrorText переводит ошибку создания примера для интерфейса
+func sampleErrorText(err error) string {
+	switch {
+	case errors.Is(err, samples.ErrInvalidName):
+		return "Имя примера: от 1 до 100 букв, цифр и символов _ - . без пробелов"
+	case errors.Is(err, samples.ErrEmptyPayload):
+		return "Укажите тело запроса или загрузите файл"
+	case errors.Is(err, samples.ErrNoLastWebhook):
+		return "Вебхуков ещё не было"
+	default:
+		return "Не удалось сохранить пример"
+	}
+}
+
+// canEditTemplate - шаблон создан пользователем или пользователь администратор
+func canEditTemplate(user *domain.User, template *domain.Template) bool {
+	if user == nil {
+		return false
+	}
+	return user.Role == "admin" || (template.CreatedBy.Valid && template.CreatedBy.String == user.ID)
+}
+
+// ================ История версий шаблонов ================
+
+// TemplateVersionsPage отображает историю версий шаблона
+func (h *Handler) TemplateVersionsPage(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "Template not found")
+	}
+
+	versions, err := h.repo.ListTemplateVersions(c.Request().Context(), template.ID)
+	if err != nil {
+		log.Error().Err(err).Str("template_id", template.ID).Msg("Failed to load template versions")
+		return c.String(http.StatusInternalServerError, "Failed to load template versions")
+	}
+
+	return pages.TemplateVersionsPage(template, versions, user).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// TemplateVersionDiff показывает построчное сравнение двух версий шаблона (HTMX).
+// По умолчанию сравнивается текущая версия с предыдущей.
+func (h *Handler) TemplateVersionDiff(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "Template not found")
+	}
+
+	to := template.Version
+	if value := c.QueryParam("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil {
+			return c.String(http.StatusBadRequest, "Invalid version")
+		}
+	}
+	from := to - 1
+	if from < 1 {
+		// У первой версии нет предыдущей: сравниваем её с собой, diff пустой
+		from = 1
+	}
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = strconv.Atoi(value); err != nil {
+			return c.String(http.StatusBadRequest, "Invalid version")
+		}
+	}
+
+	fromVersion, err := h.repo.GetTemplateVersion(c.Request().Context(), template.ID, from)
+	if err != nil {
+		return c.HTML(http.StatusOK, fmt.Sprintf(`<div class="text-red-600">Версия v%d не найдена</div>`, from))
+	}
+	toVersion, err := h.repo.GetTemplateVersion(c.Request().Context(), template.ID, to)
+	if err != nil {
+		return c.HTML(http.StatusOK, fmt.Sprintf(`<div class="text-red-600">Версия v%d не найдена</div>`, to))
+	}
+
+	lines := diff.Lines(fromVersion.TemplateText, toVersion.TemplateText)
+	return pages.TemplateVersionDiff(fromVersion, toVersion, lines).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RollbackTemplateVersion возвращает шаблон к сохранённой версии.
+// Откат записывается новой версией, поэтому история не теряется.
+func (h *Handler) RollbackTemplateVersion(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "Template not found")
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid version")
+	}
+	version, err := h.repo.GetTemplateVersion(c.Request().Context(), template.ID, number)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Template version not found")
+	}
+
+	template.Restore(version)
+	template.ChangeAuthor = userID
+	template.ChangeNote = fmt.Sprintf("Откат к версии v%d", version.Version)
+
+	// Откат - такое же сохранение: публичный шаблон с непроходящими тестами сохранить нельзя
+	if err := h.checkTemplateTests(c.Request().Context(), template); err != nil {
+		return c.String(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	if err := h.repo.UpdateTemplate(c.Request().Context(), template); err != nil {
+		log.Error().Err(err).Str("template_id", template.ID).Msg("Failed to rollback template")
+		return c.String(http.StatusInternalServerError, "Failed to rollback template")
+	}
+	log.Info().Str("template_id", template.ID).Int("version", version.Version).Int("new_version", template.Version).Msg("Template rolled back")
+
+	return c.HTML(http.StatusOK, `<script>window.location.href='/admin/templates/`+template.ID+`/versions'</script>`)
+}
+
+// ================ Импорт и экспорт шаблонов ================
+
+// ExportTemplates выгружает отмеченные шаблоны (все, если не отмечено ни одного) пакетом JSON или YAML
+func (h *Handler) ExportTemplates(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	var list []*domain.Template
+	if ids := c.QueryParams()["id"]; len(ids) > 0 {
+		for _, id := range ids {
+			template, err := h.repo.GetTemplateByID(c.Request().Context(), id)
+			if err != nil {
+				return c.String(http.StatusNotFound, "Template not found")
+			}
+			list = append(list, template)
+		}
+	} else {
+		list, err = h.repo.ListTemplates(c.Request().Context(), userID, true)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to load templates")
+			return c.String(http.StatusInternalServerError, "Failed to load templates")
+		}
+	}
+
+	b, err := bundle.Export(c.Request().Context(), h.repo, list)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to export templates")
+		return c.String(http.StatusInternalServerError, "Failed to export templates")
+	}
+
+	format := bundle.Format(c.QueryParam("format"))
+	data, err := bundle.Marshal(b, format)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode template bundle")
+		return c.String(http.StatusInternalServerError, "Failed to export templates")
+	}
+
+	log.Info().Int("count", len(list)).Str("format", format).Msg("Templates exported")
+	filename := fmt.Sprintf("templates-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Blob(http.StatusOK, bundleContentType(format), data)
+}
+
+// bundleContentType возвращает Content-Type файла пакета
+func bundleContentType(format string) string {
+	if format == bundle.FormatYAML {
+		return "application/yaml; charset=utf-8"
+	}
+	return echo.MIMEApplicationJSONCharsetUTF8
+}
+
+// TemplatesImportPage отображает страницу импорта шаблонов
+func (h *Handler) TemplatesImportPage(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	return pages.TemplatesImportPage(user).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ImportTemplates импортирует пакет из загруженного файла или текста (HTMX)
+func (h *Handler) ImportTemplates(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, err := h.repo.FindUserByID(c.Request().Context(), userID)
+	if err != nil || user.Role != "admin" {
+		return c.String(http.StatusForbidden, "Доступ запрещен")
+	}
+
+	// Файл имеет приоритет над текстом
+	data := []byte(c.FormValue("bundle"))
+	if file, err := c.FormFile("bundle_file"); err == nil {
+		src, err := file.Open()
+		if err == nil {
+			data, err = io.ReadAll(io.LimitReader(src, bundle.MaxSize))
+			src.Close()
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to read bundle file")
+			return pages.TemplatesImportReport(nil, "Не удалось прочитать файл").Render(c.Request().Context(), c.Response().Writer)
+		}
+	}
+
+	onConflict := c.FormValue("on_conflict")
+	if !bundle.ValidConflict(onConflict) {
+		return pages.TemplatesImportReport(nil, "Неизвестный режим: "+onConflict).Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	b, err := bundle.Unmarshal(data)
+	if err != nil {
+		return pages.TemplatesImportReport(nil, err.Error()).Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	report := bundle.Import(c.Request().Context(), h.repo, b, bundle.Options{OnConflict: onConflict, UserID: userID, Admin: true})
+	log.Info().
+		Int("created", report.Count(bundle.ActionCreated)+report.Count(bundle.ActionRenamed)).
+		Int("updated", report.Count(bundle.ActionUpdated)).
+		Int("skipped", report.Count(bundle.ActionSkipped)).
+		Int("failed", report.Count(bundle.ActionFailed)).
+		Msg("Templates imported")
+
+	return pages.TemplatesImportReport(report, "").Render(c.Request().Context(), c.Response().Writer)
+}
+
 // ================ Обработчики для шаблонов (пользователи) ================
 
 // TemplatesUserPage отображает список доступных шаблонов для пользователей
@@ -279,9 +726,42 @@ func (h *Handler) TemplatesUserPage(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Failed to load templates")
 	}
 
+	// Примеры данных показываются как документация к шаблону
+	ids := make([]string, 0, len(templates))
+	byID := make(map[string]*domain.Template, len(templates))
+	for _, t := range templates {
+		ids = append(ids, t.ID)
+		byID[t.ID] = t
+	}
+	if list, err := h.repo.ListTemplateSamples(c.Request().Context(), ids...); err != nil {
+		log.Error().Err(err).Msg("Failed to load template samples")
+	} else {
+		for _, sample := range list {
+			byID[sample.TemplateID].Samples = append(byID[sample.TemplateID].Samples, sample)
+		}
+	}
+
 	return pages.TemplatesUserPage(templates, user).Render(c.Request().Context(), c.Response().Writer)
 }
 
+// TemplateSample показывает пример данных шаблона в модальном окне
+func (h *Handler) TemplateSample(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), c.Param("id"))
+	if err != nil || (!template.IsPublic && !canEditTemplate(user, template)) {
+		return c.String(http.StatusNotFound, "Template not found")
+	}
+
+	sample, err := h.repo.GetTemplateSample(c.Request().Context(), template.ID, c.Param("sample_id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "Sample not found")
+	}
+
+	return pages.TemplateSampleModal(template, sample).Render(c.Request().Context(), c.Response().Writer)
+}
+
 // InstanceCreatePage отображает форму создания экземпляра из шаблона
 func (h *Handler) InstanceCreatePage(c echo.Context) error {
 	userID := getUserIDFromContext(c)
@@ -407,45 +887,102 @@ func (h *Handler) TestInstance(c echo.Context) error {
 	return c.HTML(http.StatusOK, `<div class="bg-green-100 border border-green-400 text-green-700 px-4 py-3 rounded">✓ Тестовое сообщение отправлено</div>`)
 }
 
-// EditInstanceForm отображает форму редактирования экземпляра
-func (h *Handler) EditInstanceForm(c echo.Context) error {
+// ReplayInstance прогоняет последний полученный вебхук через фильтры, маршруты и шаблон
+// и отправляет результат, показывая отрендеренный текст и ответ мессенджера
+func (h *Handler) ReplayInstance(c echo.Context) error {
 	id := c.Param("id")
 	userID := getUserIDFromContext(c)
 
 	instance, err := h.repo.GetInstanceWithTemplate(c.Request().Context(), id, userID)
 	if err != nil {
-		return c.String(http.StatusNotFound, "Instance not found")
+		return c.String(http.StatusNotFound, "Интеграция не найдена")
 	}
 
-	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
-	return pages.InstanceEditPage(instance, user).Render(c.Request().Context(), c.Response().Writer)
-}
+	result, err := h.webhooks.Replay(c.Request().Context(), instance)
+	if errors.Is(err, webhook.ErrNoLastWebhook) {
+		return c.HTML(http.StatusOK, `<div class="bg-yellow-100 border border-yellow-400 text-yellow-700 px-4 py-3 rounded">Вебхуков ещё не было</div>`)
+	}
+	if errors.Is(err, webhook.ErrNoTemplate) {
+		return c.HTML(http.StatusOK, `<div class="bg-yellow-100 border border-yellow-400 text-yellow-700 px-4 py-3 rounded">У интеграции нет шаблона</div>`)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Replay failed")
+		return c.HTML(http.StatusInternalServerError, fmt.Sprintf(`<div class="bg-red-100 border border-red-400 text-red-700 px-4 py-3 rounded">Ошибка: %s</div>`, html.EscapeString(err.Error())))
+	}
 
-// UpdateInstance обновляет экземпляр интеграции
-func (h *Handler) UpdateInstance(c echo.Context) error {
-	log.Info().
-		Str("method", c.Request().Method).
-		Str("path", c.Request().URL.Path).
-		Str("_method", c.FormValue("_method")).
-		Str("id", c.Param("id")).
-		Msg("🚀 UpdateInstance called")
+	return pages.ReplayResult(result).Render(c.Request().Context(), c.Response().Writer)
+}
 
+// PreviewInstanceTemplate рендерит шаблон из редактора экземпляра на последнем вебхуке,
+// примере данных шаблона или вставленных данных (HTMX, обновляется при вводе)
+func (h *Handler) PreviewInstanceTemplate(c echo.Context) error {
 	id := c.Param("id")
 	userID := getUserIDFromContext(c)
 
-	// Загружаем существующий экземпляр
 	instance, err := h.repo.GetInstanceWithTemplate(c.Request().Context(), id, userID)
 	if err != nil {
 		return c.String(http.StatusNotFound, "Instance not found")
 	}
 
-	// Обновляем поля экземпляра
-	instance.Name = c.FormValue("name")
-	instance.ChatID = c.FormValue("chat_id")
-	instance.IsActive = c.FormValue("is_active") == "on"
+	text := c.FormValue("template_text")
+	if text == "" && instance.Template != nil {
+		text = instance.Template.TemplateText
+	}
+	h.loadSamples(c.Request().Context(), instance.Template)
 
-	// Обновляем токен если изменился
-	if token := c.FormValue("bot_token"); token != "" && token != "***" {
+	result := preview.Preview(text, c.FormValue("preview_source"), c.FormValue("preview_sample"), c.FormValue("preview_payload"), instance, instance.Template)
+	return pages.TemplatePreviewResult(result).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// EditInstanceForm отображает форму редактирования экземпляра
+func (h *Handler) EditInstanceForm(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	instance, err := h.repo.GetInstanceWithTemplate(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	h.loadSamples(c.Request().Context(), instance.Template)
+
+	var versions []*domain.TemplateVersion
+	if instance.Template != nil {
+		versions, err = h.repo.ListTemplateVersions(c.Request().Context(), instance.Template.ID)
+		if err != nil {
+			log.Warn().Err(err).Str("template_id", instance.Template.ID).Msg("Failed to load template versions")
+		}
+	}
+
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+	return pages.InstanceEditPage(instance, versions, user).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// UpdateInstance обновляет экземпляр интеграции
+func (h *Handler) UpdateInstance(c echo.Context) error {
+	log.Info().
+		Str("method", c.Request().Method).
+		Str("path", c.Request().URL.Path).
+		Str("_method", c.FormValue("_method")).
+		Str("id", c.Param("id")).
+		Msg("🚀 UpdateInstance called")
+
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	// Загружаем существующий экземпляр
+	instance, err := h.repo.GetInstanceWithTemplate(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	// Обновляем поля экземпляра
+	instance.Name = c.FormValue("name")
+	instance.ChatID = c.FormValue("chat_id")
+	instance.IsActive = c.FormValue("is_active") == "on"
+
+	// Обновляем токен если изменился
+	if token := c.FormValue("bot_token"); token != "" && token != "***" {
 		encryptedToken, err := h.encryptor.Encrypt(token)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to encrypt bot token")
@@ -454,12 +991,72 @@ func (h *Handler) UpdateInstance(c echo.Context) error {
 		instance.BotToken = encryptedToken
 	}
 
-	// Обновляем шаблон если он изменился
-	if templateText := c.FormValue("template_text"); templateText != "" && instance.Template != nil {
-		instance.Template.TemplateText = templateText
-		if err := h.repo.UpdateTemplate(c.Request().Context(), instance.Template); err != nil {
-			log.Error().Err(err).Msg("Failed to update template")
-			return c.String(http.StatusInternalServerError, "Failed to update template")
+	// Обновляем настройки проверки вебхуков
+	if err := h.applyVerificationForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем настройки группировки сообщений
+	if err := applyCorrelationForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем настройки режима Alertmanager
+	if err := applyAlertmanagerForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем настройки дедупликации вебхуков
+	if err := applyDedupForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем правила фильтрации событий
+	if err := applyFilterForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем маршруты
+	if err := applyRoutesForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем настройки личных сообщений
+	if err := applyDirectForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Закрепляем версию шаблона. Текст закреплённой версии не редактируется:
+	// в instance.Template уже подставлен снимок, и сохранять его поверх шаблона нельзя.
+	pinned := instance.TemplateVersion != nil
+	if err := h.applyTemplateVersionForm(c, instance); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// Обновляем шаблон если он изменился. Общий шаблон не меняется:
+	// правка сохраняется в личную копию, и экземпляр переключается на неё.
+	templateText := c.FormValue("template_text")
+	if templateText != "" && instance.Template != nil && !pinned && instance.TemplateVersion == nil &&
+		templateText != instance.Template.TemplateText {
+		if fork.Shared(instance.Template, userID) {
+			original := instance.Template
+			copied, err := fork.Instance(c.Request().Context(), h.repo, instance, userID, templateText)
+			if err != nil {
+				log.Error().Err(err).Str("template_id", original.ID).Msg("Failed to fork template")
+				return c.String(http.StatusInternalServerError, "Failed to fork template")
+			}
+			log.Info().Str("id", id).Str("template_id", original.ID).Str("fork_id", copied.ID).Msg("Shared template forked for instance")
+		} else {
+			instance.Template.TemplateText = templateText
+			instance.Template.ChangeAuthor = userID
+			instance.Template.ChangeNote = "Изменено в интеграции " + instance.Name
+			if err := h.checkTemplateTests(c.Request().Context(), instance.Template); err != nil {
+				return c.String(http.StatusUnprocessableEntity, err.Error())
+			}
+			if err := h.repo.UpdateTemplate(c.Request().Context(), instance.Template); err != nil {
+				log.Error().Err(err).Msg("Failed to update template")
+				return c.String(http.StatusInternalServerError, "Failed to update template")
+			}
 		}
 	}
 
@@ -475,6 +1072,257 @@ func (h *Handler) UpdateInstance(c echo.Context) error {
 	return c.HTML(http.StatusOK, `<script>window.location.href='/instances'</script>`)
 }
 
+// applyTemplateVersionForm закрепляет экземпляр за версией шаблона из формы.
+// Пустое значение означает «последняя версия».
+func (h *Handler) applyTemplateVersionForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	value := c.FormValue("template_version")
+	if value == "" || instance.Template == nil {
+		instance.TemplateVersion = nil
+		return nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid template version: %s", value)
+	}
+	if _, err := h.repo.GetTemplateVersion(c.Request().Context(), instance.Template.ID, number); err != nil {
+		return fmt.Errorf("template version v%d not found", number)
+	}
+	instance.TemplateVersion = &number
+	return nil
+}
+
+// applyVerificationForm переносит настройки проверки вебхуков из формы в CustomSettings.
+// Пустой секрет означает «оставить текущий».
+func (h *Handler) applyVerificationForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	settings := &verification.Settings{
+		Mode: c.FormValue("verification_mode"),
+	}
+	if settings.Mode == verification.ModeNone {
+		settings.Store(instance.CustomSettings)
+		return nil
+	}
+
+	switch settings.Mode {
+	case verification.ModeHMAC:
+		settings.Header = strings.TrimSpace(c.FormValue("verification_header"))
+		settings.Algorithm = c.FormValue("verification_algorithm")
+	case verification.ModeBasic:
+		settings.Username = c.FormValue("verification_username")
+	case verification.ModeIPAllowlist:
+		settings.AllowedIPs = verification.ParseAllowedIPs(c.FormValue("verification_allowed_ips"))
+	}
+
+	current, _ := verification.FromCustomSettings(instance.CustomSettings)
+	if err := settings.SetSecret(h.encryptor, c.FormValue("verification_secret"), current); err != nil {
+		log.Error().Err(err).Msg("Failed to encrypt verification secret")
+		return fmt.Errorf("failed to encrypt secret")
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyCorrelationForm переносит настройки группировки сообщений из формы в CustomSettings
+func applyCorrelationForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	settings := &correlation.Settings{
+		Key:  strings.TrimSpace(c.FormValue("correlation_key")),
+		Mode: c.FormValue("correlation_mode"),
+		TTL:  strings.TrimSpace(c.FormValue("correlation_ttl")),
+	}
+	if settings.Key != "" {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyAlertmanagerForm переносит настройки режима Alertmanager из формы в CustomSettings
+func applyAlertmanagerForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	settings := &alertmanager.Settings{
+		Enabled: c.FormValue("alertmanager_enabled") == "on",
+		Split:   c.FormValue("alertmanager_split"),
+		Resolve: c.FormValue("alertmanager_resolve"),
+		Dedup:   c.FormValue("alertmanager_dedup") == "on",
+		TTL:     strings.TrimSpace(c.FormValue("alertmanager_ttl")),
+	}
+	if settings.Enabled {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyDedupForm переносит настройки дедупликации вебхуков из формы в CustomSettings
+func applyDedupForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	settings := &dedup.Settings{
+		Enabled: c.FormValue("dedup_enabled") == "on",
+		Header:  strings.TrimSpace(c.FormValue("dedup_header")),
+		Key:     strings.TrimSpace(c.FormValue("dedup_key")),
+		Window:  strings.TrimSpace(c.FormValue("dedup_window")),
+	}
+	if settings.Enabled {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyFilterForm переносит правила фильтрации из формы в CustomSettings.
+// Строки правил приходят параллельными списками filter_match, filter_field, filter_value и filter_action.
+func applyFilterForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return err
+	}
+
+	matches := form["filter_match"]
+	fields := form["filter_field"]
+	values := form["filter_value"]
+	actions := form["filter_action"]
+	if len(fields) != len(matches) || len(values) != len(matches) || len(actions) != len(matches) {
+		return fmt.Errorf("malformed filter rules")
+	}
+
+	settings := &filter.Settings{Default: c.FormValue("filter_default")}
+	for i, match := range matches {
+		settings.Rules = append(settings.Rules, filter.Rule{
+			Condition: formCondition(match, fields[i], values[i]),
+			Action:    actions[i],
+		})
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyRoutesForm переносит маршруты из формы в CustomSettings.
+// Строки маршрутов приходят параллельными списками route_*.
+func applyRoutesForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return err
+	}
+
+	matches := form["route_match"]
+	columns := [][]string{form["route_field"], form["route_value"], form["route_chat_id"], form["route_login"], form["route_template"], form["route_continue"], form["route_name"]}
+	for _, column := range columns {
+		if len(column) != len(matches) {
+			return fmt.Errorf("malformed routes")
+		}
+	}
+
+	settings := &routing.Settings{}
+	for i, match := range matches {
+		settings.Routes = append(settings.Routes, routing.Route{
+			Name:      form["route_name"][i],
+			Condition: formCondition(match, form["route_field"][i], form["route_value"][i]),
+			ChatID:    strings.TrimSpace(form["route_chat_id"][i]),
+			Login:     strings.TrimSpace(form["route_login"][i]),
+			Template:  form["route_template"][i],
+			Continue:  form["route_continue"][i] == "true",
+		})
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// applyDirectForm переносит настройки личных сообщений из формы в CustomSettings
+func applyDirectForm(c echo.Context, instance *domain.IntegrationInstance) error {
+	if instance.CustomSettings == nil {
+		instance.CustomSettings = map[string]interface{}{}
+	}
+
+	settings := &recipients.Settings{
+		Enabled:    c.FormValue("direct_enabled") == "on",
+		Recipients: strings.TrimSpace(c.FormValue("direct_recipients")),
+		Domain:     strings.TrimSpace(c.FormValue("direct_domain")),
+		KeepChat:   c.FormValue("direct_keep_chat") == "on",
+	}
+	if settings.Enabled {
+		logins, err := recipients.ParseLogins(c.FormValue("direct_logins"))
+		if err != nil {
+			return err
+		}
+		settings.Logins = logins
+
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+	}
+
+	settings.Store(instance.CustomSettings)
+	return nil
+}
+
+// formCondition собирает условие из строки формы: поле (путь, заголовок или метка) и значение
+func formCondition(match, field, value string) filter.Condition {
+	condition := filter.Condition{Match: match}
+	field = strings.TrimSpace(field)
+	switch match {
+	case filter.MatchJSONPath:
+		condition.Path = field
+		condition.Pattern = value
+	case filter.MatchHeader:
+		condition.Header = field
+		condition.Pattern = value
+	case filter.MatchLabel:
+		condition.Label = field
+		condition.Pattern = value
+	case filter.MatchRegex:
+		condition.Pattern = value
+	case filter.MatchLiquid:
+		condition.Expr = strings.TrimSpace(value)
+	}
+	return condition
+}
+
 // DeleteInstance удаляет экземпляр интеграции
 func (h *Handler) DeleteInstance(c echo.Context) error {
 	id := c.Param("id")
@@ -491,6 +1339,37 @@ func (h *Handler) DeleteInstance(c echo.Context) error {
 	return c.HTML(http.StatusOK, `<script>window.location.href='/instances'</script>`)
 }
 
+// CaptureSample сохраняет последний вебхук экземпляра как пример данных его шаблона (HTMX).
+// Доступно автору шаблона и администратору.
+func (h *Handler) CaptureSample(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+	template, err := h.repo.GetTemplateByID(c.Request().Context(), instance.TemplateID)
+	if err != nil || !canEditTemplate(user, template) {
+		return c.HTML(http.StatusOK, `<span class="text-red-700">Нет прав на изменение шаблона</span>`)
+	}
+
+	sample, err := samples.FromLastWebhook(instance, strings.TrimSpace(c.FormValue("name")), c.FormValue("description"))
+	if err == nil {
+		err = h.repo.SaveTemplateSample(c.Request().Context(), sample)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to capture template sample")
+		return c.HTML(http.StatusOK, fmt.Sprintf(`<span class="text-red-700">%s</span>`, html.EscapeString(sampleErrorText(err))))
+	}
+
+	log.Info().Str("instance_id", id).Str("template_id", template.ID).Str("sample", sample.Name).Msg("Last webhook saved as template sample")
+	return c.HTML(http.StatusOK, fmt.Sprintf(`<span class="text-green-700">✓ Пример %s сохранён в шаблон %s</span>`,
+		html.EscapeString(sample.Name), html.EscapeString(template.Name)))
+}
+
 // GetLastWebhook возвращает последний вебхук для экземпляра
 func (h *Handler) GetLastWebhook(c echo.Context) error {
 	id := c.Param("id")
@@ -507,11 +1386,32 @@ func (h *Handler) GetLastWebhook(c echo.Context) error {
 
 	// Форматируем JSON для красивого отображения
 	var prettyHeaders, prettyBody bytes.Buffer
-	json.Indent(&prettyHeaders, instance.LastWebhookHeaders, "", "  ")
-	json.Indent(&prettyBody, instance.LastWebhookBody, "", "  ")
+	json.Indent(&prettyHeaders, payload.RedactStoredHeaders(instance.LastWebhookHeaders), "", "  ")
+
+	// Тело не в формате JSON (форма, XML, текст) показываем как есть
+	body, _ := payload.Stored(nil, instance.LastWebhookBody)
+	if json.Indent(&prettyBody, body, "", "  ") != nil {
+		prettyBody.Reset()
+		prettyBody.Write(body)
+	}
+
+	headersStr := html.EscapeString(prettyHeaders.String())
+	bodyStr := html.EscapeString(prettyBody.String())
 
-	headersStr := prettyHeaders.String()
-	bodyStr := prettyBody.String()
+	// Сохранение запроса как примера данных шаблона - для автора шаблона и администратора
+	captureForm := ""
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+	if template, err := h.repo.GetTemplateByID(c.Request().Context(), instance.TemplateID); err == nil && canEditTemplate(user, template) {
+		captureForm = fmt.Sprintf(`
+                    <form class="flex items-center gap-2" hx-post="/instances/%s/last-webhook/sample" hx-target="#sample-capture-result" hx-swap="innerHTML">
+                        <input type="text" name="name" required placeholder="issue_created"
+                               class="px-2 py-1 border border-gray-300 rounded-md text-sm font-mono"/>
+                        <button type="submit" class="text-sm bg-blue-600 text-white hover:bg-blue-700 px-3 py-1 rounded">
+                            Сохранить как пример шаблона
+                        </button>
+                        <span id="sample-capture-result" class="text-sm"></span>
+                    </form>`, html.EscapeString(instance.ID))
+	}
 
 	return c.HTML(http.StatusOK, fmt.Sprintf(`
         <div class="fixed inset-0 bg-gray-600 bg-opacity-50 overflow-y-auto h-full w-full z-50" id="webhook-modal">
@@ -555,7 +1455,8 @@ func (h *Handler) GetLastWebhook(c echo.Context) error {
                     <pre class="bg-gray-50 p-3 rounded text-sm overflow-auto font-mono border" style="max-height: 600px;" id="body-content">%s</pre>
                 </div>
                 
-                <div class="mt-4 flex justify-end">
+                <div class="mt-4 flex justify-between items-center">
+                    <div>%s</div>
                     <button onclick="document.getElementById('webhook-modal').remove()" 
                             class="px-4 py-2 bg-gray-200 text-gray-800 rounded-md hover:bg-gray-300 transition">
                         Закрыть
@@ -589,9 +1490,239 @@ func (h *Handler) GetLastWebhook(c echo.Context) error {
 		instance.LastWebhookAt.Format("02.01.2006 15:04:05"),
 		headersStr,
 		bodyStr,
+		captureForm,
 	))
 }
 
+// InstanceHistoryPage отображает историю доставок экземпляра
+func (h *Handler) InstanceHistoryPage(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filter := domain.DeliveryLogFilter{
+		Kind:   c.QueryParam("kind"),
+		Status: c.QueryParam("status"),
+		Limit:  pages.HistoryPageSize,
+		Offset: (page - 1) * pages.HistoryPageSize,
+	}
+
+	logs, total, err := h.repo.GetDeliveryLogs(c.Request().Context(), id, userID, filter)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to load delivery logs")
+		return c.String(http.StatusInternalServerError, "Failed to load history")
+	}
+
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+	return pages.InstanceHistoryPage(instance, logs, total, filter, page, user).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// DeadLettersPage отображает недоставленные сообщения экземпляра
+func (h *Handler) DeadLettersPage(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	letters, err := h.repo.ListDeadLetters(c.Request().Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to load dead letters")
+		return c.String(http.StatusInternalServerError, "Failed to load dead letters")
+	}
+
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+	return pages.DeadLettersPage(instance, letters, user).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RedeliverDeadLetter ставит недоставленное сообщение в очередь повторно
+func (h *Handler) RedeliverDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	letterID, err := strconv.ParseInt(c.Param("letter_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid letter ID")
+	}
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	if err := h.repo.RedeliverDeadLetter(c.Request().Context(), id, letterID, c.FormValue("message"), c.FormValue("chat_id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Dead letter not found")
+		}
+		log.Error().Err(err).Int64("outbox_id", letterID).Msg("Failed to redeliver dead letter")
+		return c.String(http.StatusInternalServerError, "Failed to redeliver dead letter")
+	}
+
+	log.Info().Str("instance_id", id).Int64("outbox_id", letterID).Msg("Dead letter queued for redelivery")
+	return h.renderDeadLetters(c, instance)
+}
+
+// RedeliverAllDeadLetters ставит в очередь все недоставленные сообщения экземпляра
+func (h *Handler) RedeliverAllDeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	count, err := h.repo.RedeliverAllDeadLetters(c.Request().Context(), id, c.FormValue("chat_id"))
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to redeliver dead letters")
+		return c.String(http.StatusInternalServerError, "Failed to redeliver dead letters")
+	}
+
+	log.Info().Str("instance_id", id).Int64("count", count).Msg("Dead letters queued for redelivery")
+	return h.renderDeadLetters(c, instance)
+}
+
+// DiscardDeadLetter удаляет недоставленное сообщение
+func (h *Handler) DiscardDeadLetter(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	letterID, err := strconv.ParseInt(c.Param("letter_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid letter ID")
+	}
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	if err := h.repo.DiscardDeadLetter(c.Request().Context(), id, letterID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Dead letter not found")
+		}
+		log.Error().Err(err).Int64("outbox_id", letterID).Msg("Failed to discard dead letter")
+		return c.String(http.StatusInternalServerError, "Failed to discard dead letter")
+	}
+
+	return h.renderDeadLetters(c, instance)
+}
+
+// DiscardAllDeadLetters удаляет все недоставленные сообщения экземпляра
+func (h *Handler) DiscardAllDeadLetters(c echo.Context) error {
+	id := c.Param("id")
+	userID := getUserIDFromContext(c)
+
+	instance, err := h.repo.GetInstanceByID(c.Request().Context(), id, userID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Instance not found")
+	}
+
+	if _, err := h.repo.DiscardAllDeadLetters(c.Request().Context(), id); err != nil {
+		log.Error().Err(err).Str("instance_id", id).Msg("Failed to discard dead letters")
+		return c.String(http.StatusInternalServerError, "Failed to discard dead letters")
+	}
+
+	return h.renderDeadLetters(c, instance)
+}
+
+// renderDeadLetters возвращает обновлённый список недоставленных сообщений для HTMX
+func (h *Handler) renderDeadLetters(c echo.Context, instance *domain.IntegrationInstance) error {
+	letters, err := h.repo.ListDeadLetters(c.Request().Context(), instance.ID)
+	if err != nil {
+		log.Error().Err(err).Str("instance_id", instance.ID).Msg("Failed to load dead letters")
+		return c.String(http.StatusInternalServerError, "Failed to load dead letters")
+	}
+
+	return pages.DeadLettersList(instance, letters).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// APIKeysPage отображает API-ключи пользователя
+func (h *Handler) APIKeysPage(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	keys, err := h.repo.ListAPIKeys(c.Request().Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load api keys")
+		return c.String(http.StatusInternalServerError, "Failed to load API keys")
+	}
+
+	user, _ := h.repo.FindUserByID(c.Request().Context(), userID)
+	return pages.APIKeysPage(keys, user).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// CreateAPIKey выпускает новый API-ключ и показывает его один раз
+func (h *Handler) CreateAPIKey(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return c.String(http.StatusBadRequest, "Name is required")
+	}
+
+	plainKey, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to generate api key")
+		return c.String(http.StatusInternalServerError, "Failed to generate key")
+	}
+
+	key := &domain.APIKey{
+		UserID:    userID,
+		Name:      name,
+		KeyHash:   hash,
+		KeyPrefix: prefix,
+		ReadOnly:  c.FormValue("read_only") == "on",
+	}
+	if days, err := strconv.Atoi(c.FormValue("expires_in")); err == nil && days > 0 {
+		expiresAt := time.Now().AddDate(0, 0, days)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := h.repo.CreateAPIKey(c.Request().Context(), key); err != nil {
+		log.Error().Err(err).Msg("Failed to create api key")
+		return c.String(http.StatusInternalServerError, "Failed to create key")
+	}
+
+	log.Info().Str("key_id", key.ID).Str("user_id", userID).Bool("read_only", key.ReadOnly).Msg("API key created")
+	return h.renderAPIKeys(c, userID, plainKey)
+}
+
+// RevokeAPIKey отзывает API-ключ
+func (h *Handler) RevokeAPIKey(c echo.Context) error {
+	userID := getUserIDFromContext(c)
+	id := c.Param("id")
+
+	if err := h.repo.DeleteAPIKey(c.Request().Context(), id, userID); err != nil {
+		log.Error().Err(err).Str("key_id", id).Msg("Failed to revoke api key")
+		return c.String(http.StatusNotFound, "Key not found")
+	}
+
+	log.Info().Str("key_id", id).Str("user_id", userID).Msg("API key revoked")
+	return h.renderAPIKeys(c, userID, "")
+}
+
+// renderAPIKeys возвращает обновлённый список ключей для HTMX
+func (h *Handler) renderAPIKeys(c echo.Context, userID string, newKey string) error {
+	keys, err := h.repo.ListAPIKeys(c.Request().Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load api keys")
+		return c.String(http.StatusInternalServerError, "Failed to load API keys")
+	}
+
+	return pages.APIKeysList(keys, newKey).Render(c.Request().Context(), c.Response().Writer)
+}
+
 // CustomInstanceCreatePage отображает форму создания кастомной интеграции
 func (h *Handler) CustomInstanceCreatePage(c echo.Context) error {
 	userID := getUserIDFromContext(c)
@@ -632,6 +1763,7 @@ func (h *Handler) CreateCustomInstance(c echo.Context) error {
 		TemplateText: templateText,
 		IsPublic:     false,
 		CreatedBy:    sql.NullString{String: userID, Valid: true},
+		ChangeAuthor: userID,
 	}
 
 	if err := h.repo.CreateTemplate(c.Request().Context(), template); err != nil {
@@ -6,6 +6,7 @@ import (
 	"yandex-messenger-bridge/internal/repository/interface"
 	"yandex-messenger-bridge/internal/transport/middleware"
 	"yandex-messenger-bridge/internal/service/encryption"
+	"yandex-messenger-bridge/internal/service/webhook"
 )
 
 func SetupRoutes(
@@ -13,8 +14,9 @@ func SetupRoutes(
 	repo _interface.IntegrationRepository,
 	authMiddleware *middleware.AuthMiddleware,
 	encryptor *encryption.Encryptor,
+	webhooks *webhook.Handler,
 ) {
-	handler := NewHandler(repo, encryptor)
+	handler := NewHandler(repo, encryptor, webhooks)
 
 	// Публичные маршруты
 	e.GET("/login", handler.LoginPage)
@@ -31,17 +33,42 @@ func SetupRoutes(
 		protected.GET("/admin/templates/new", handler.TemplateEditPage)
 		protected.GET("/admin/templates/:id/edit", handler.TemplateEditPage)
 		protected.POST("/admin/templates", handler.CreateTemplate)
+		protected.POST("/admin/templates/preview", handler.PreviewTemplate)
+		protected.POST("/admin/templates/tests", handler.RunTemplateTests)
+		protected.GET("/admin/templates/export", handler.ExportTemplates)
+		protected.GET("/admin/templates/import", handler.TemplatesImportPage)
+		protected.POST("/admin/templates/import", handler.ImportTemplates)
 		protected.DELETE("/admin/templates/:id", handler.DeleteTemplate)
+		protected.POST("/admin/templates/:id/samples", handler.SaveTemplateSample)
+		protected.DELETE("/admin/templates/:id/samples/:sample_id", handler.DeleteTemplateSample)
+		protected.GET("/admin/templates/:id/versions", handler.TemplateVersionsPage)
+		protected.GET("/admin/templates/:id/versions/diff", handler.TemplateVersionDiff)
+		protected.POST("/admin/templates/:id/versions/:version/rollback", handler.RollbackTemplateVersion)
 
 		// Пользовательские маршруты для шаблонов и экземпляров
 		protected.GET("/templates", handler.TemplatesUserPage)
 		protected.GET("/templates/:id/use", handler.InstanceCreatePage)
+		protected.GET("/templates/:id/samples/:sample_id", handler.TemplateSample)
 		protected.POST("/instances", handler.CreateInstance)
 		protected.GET("/instances", handler.InstancesListPage)
 		protected.POST("/instances/:id/test", handler.TestInstance)
+		protected.POST("/instances/:id/replay", handler.ReplayInstance)
 		protected.DELETE("/instances/:id", handler.DeleteInstance)
 		protected.GET("/instances/:id/edit", handler.EditInstanceForm)
+		protected.POST("/instances/:id/preview", handler.PreviewInstanceTemplate)
 		protected.PUT("/instances/:id", handler.UpdateInstance)
 		protected.GET("/instances/:id/last-webhook", handler.GetLastWebhook)
+		protected.POST("/instances/:id/last-webhook/sample", handler.CaptureSample)
+		protected.GET("/instances/:id/history", handler.InstanceHistoryPage)
+		protected.GET("/instances/:id/dead-letters", handler.DeadLettersPage)
+		protected.POST("/instances/:id/dead-letters/redeliver", handler.RedeliverAllDeadLetters)
+		protected.POST("/instances/:id/dead-letters/:letter_id/redeliver", handler.RedeliverDeadLetter)
+		protected.DELETE("/instances/:id/dead-letters/:letter_id", handler.DiscardDeadLetter)
+		protected.DELETE("/instances/:id/dead-letters", handler.DiscardAllDeadLetters)
+
+		// Персональные API-ключи
+		protected.GET("/api-keys", handler.APIKeysPage)
+		protected.POST("/api-keys", handler.CreateAPIKey)
+		protected.DELETE("/api-keys/:id", handler.RevokeAPIKey)
 	}
 }
@@ -0,0 +1,83 @@
+package pages
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// HistoryPageSize - количество записей на странице истории доставок
+const HistoryPageSize = 50
+
+// historyURL формирует ссылку на страницу истории с сохранением фильтров
+func historyURL(instanceID string, filter domain.DeliveryLogFilter, page int) string {
+	q := url.Values{}
+	if filter.Kind != "" {
+		q.Set("kind", filter.Kind)
+	}
+	if filter.Status != "" {
+		q.Set("status", filter.Status)
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+
+	u := "/instances/" + instanceID + "/history"
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
+
+// historyTotalPages возвращает количество страниц истории
+func historyTotalPages(total int) int {
+	pages := (total + HistoryPageSize - 1) / HistoryPageSize
+	if pages < 1 {
+		return 1
+	}
+	return pages
+}
+
+// deliveryStatusClass возвращает CSS-классы бейджа статуса доставки
+func deliveryStatusClass(status string) string {
+	switch status {
+	case domain.DeliveryStatusSent, domain.DeliveryStatusAccepted:
+		return "bg-green-100 text-green-800"
+	case domain.DeliveryStatusFailed:
+		return "bg-yellow-100 text-yellow-800"
+	case domain.DeliveryStatusDead, domain.DeliveryStatusError, domain.DeliveryStatusRejected:
+		return "bg-red-100 text-red-800"
+	default:
+		return "bg-gray-100 text-gray-800"
+	}
+}
+
+// deliveryKindLabel возвращает название типа записи истории
+func deliveryKindLabel(kind string) string {
+	switch kind {
+	case domain.DeliveryKindReceived:
+		return "📥 Вебхук"
+	case domain.DeliveryKindAttempt:
+		return "📤 Отправка"
+	default:
+		return kind
+	}
+}
+
+// deref возвращает значение строки или пустую строку для nil
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// responseStatusText возвращает HTTP-статус ответа Bot API для отображения
+func responseStatusText(status *int) string {
+	if status == nil {
+		return "—"
+	}
+	return fmt.Sprint(*status)
+}
@@ -0,0 +1,32 @@
+package pages
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/samples"
+)
+
+// samplePayloadText возвращает тело примера для отображения: JSON с отступами, остальное как есть
+func samplePayloadText(sample *domain.TemplateSample) string {
+	body, _ := samples.Body(sample)
+
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, body, "", "  ") != nil {
+		return string(body)
+	}
+	return pretty.String()
+}
+
+// sampleContentType возвращает Content-Type, с которым разбирается пример
+func sampleContentType(sample *domain.TemplateSample) string {
+	body, header := samples.Body(sample)
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	if json.Valid(body) {
+		return "application/json"
+	}
+	return "text/plain"
+}
@@ -0,0 +1,19 @@
+package pages
+
+import (
+	"encoding/json"
+
+	"yandex-messenger-bridge/internal/domain"
+)
+
+// templateTestsJSON возвращает тесты шаблона для поля редактора
+func templateTestsJSON(template *domain.Template) string {
+	if template == nil || len(template.Tests) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(template.Tests, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,53 @@
+package pages
+
+import (
+	"strconv"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/diff"
+)
+
+// versionAuthor возвращает автора версии шаблона для отображения
+func versionAuthor(version *domain.TemplateVersion) string {
+	if version.AuthorEmail != nil && *version.AuthorEmail != "" {
+		return *version.AuthorEmail
+	}
+	return "—"
+}
+
+// versionOptionLabel возвращает подпись версии в списке выбора
+func versionOptionLabel(version *domain.TemplateVersion) string {
+	label := "v" + strconv.Itoa(version.Version) + " · " + version.CreatedAt.Format("02.01.2006 15:04")
+	if version.Note != "" {
+		label += " · " + version.Note
+	}
+	return label
+}
+
+// pinnedVersion возвращает закреплённую версию шаблона экземпляра или пустую строку
+func pinnedVersion(instance *domain.IntegrationInstance) string {
+	if instance.TemplateVersion == nil {
+		return ""
+	}
+	return strconv.Itoa(*instance.TemplateVersion)
+}
+
+// diffLineClass возвращает CSS-классы строки сравнения
+func diffLineClass(op string) string {
+	switch op {
+	case diff.OpInsert:
+		return "bg-green-50 text-green-800"
+	case diff.OpDelete:
+		return "bg-red-50 text-red-800"
+	default:
+		return "text-gray-700"
+	}
+}
+
+// diffLineNumber возвращает номер строки сравнения (пусто, если строки нет в этой версии)
+func diffLineNumber(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
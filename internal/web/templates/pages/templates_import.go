@@ -0,0 +1,31 @@
+package pages
+
+import "yandex-messenger-bridge/internal/service/bundle"
+
+// importActionLabel возвращает название результата импорта шаблона
+func importActionLabel(action string) string {
+	switch action {
+	case bundle.ActionCreated:
+		return "создан"
+	case bundle.ActionUpdated:
+		return "перезаписан"
+	case bundle.ActionRenamed:
+		return "создан под другим названием"
+	case bundle.ActionSkipped:
+		return "пропущен"
+	default:
+		return "ошибка"
+	}
+}
+
+// importActionClass возвращает CSS-классы бейджа результата импорта
+func importActionClass(action string) string {
+	switch action {
+	case bundle.ActionCreated, bundle.ActionUpdated, bundle.ActionRenamed:
+		return "bg-green-100 text-green-800"
+	case bundle.ActionSkipped:
+		return "bg-gray-100 text-gray-800"
+	default:
+		return "bg-red-100 text-red-800"
+	}
+}
@@ -0,0 +1,191 @@
+package pages
+
+import (
+	"encoding/json"
+
+	"yandex-messenger-bridge/internal/domain"
+	"yandex-messenger-bridge/internal/service/alertmanager"
+	"yandex-messenger-bridge/internal/service/correlation"
+	"yandex-messenger-bridge/internal/service/dedup"
+	"yandex-messenger-bridge/internal/service/filter"
+	"yandex-messenger-bridge/internal/service/recipients"
+	"yandex-messenger-bridge/internal/service/routing"
+	"yandex-messenger-bridge/internal/service/verification"
+)
+
+// verificationSettings возвращает настройки проверки вебхуков экземпляра для формы
+func verificationSettings(instance *domain.IntegrationInstance) *verification.Settings {
+	settings, err := verification.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &verification.Settings{}
+	}
+	return settings
+}
+
+// verificationAlgorithm возвращает выбранный алгоритм HMAC
+func verificationAlgorithm(settings *verification.Settings) string {
+	if settings.Algorithm == "" {
+		return verification.AlgorithmSHA256
+	}
+	return settings.Algorithm
+}
+
+// verificationSecretPlaceholder подсказывает, задан ли уже секрет
+func verificationSecretPlaceholder(settings *verification.Settings) string {
+	if settings.Secret != "" {
+		return "••••••••"
+	}
+	return ""
+}
+
+// correlationSettings возвращает настройки группировки сообщений экземпляра для формы
+func correlationSettings(instance *domain.IntegrationInstance) *correlation.Settings {
+	settings, err := correlation.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &correlation.Settings{}
+	}
+	return settings
+}
+
+// correlationMode возвращает выбранный режим группировки
+func correlationMode(settings *correlation.Settings) string {
+	if settings.Mode == "" {
+		return correlation.ModeThread
+	}
+	return settings.Mode
+}
+
+// alertmanagerSettings возвращает настройки режима Alertmanager экземпляра для формы
+func alertmanagerSettings(instance *domain.IntegrationInstance) *alertmanager.Settings {
+	settings, err := alertmanager.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &alertmanager.Settings{Dedup: true}
+	}
+	return settings
+}
+
+// boolJS возвращает значение переключателя для x-data Alpine
+func boolJS(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+// alertmanagerSplit возвращает выбранный режим разбиения
+func alertmanagerSplit(settings *alertmanager.Settings) string {
+	if settings.Split == "" {
+		return alertmanager.SplitGroup
+	}
+	return settings.Split
+}
+
+// alertmanagerResolve возвращает выбранный способ отметки решённых алертов
+func alertmanagerResolve(settings *alertmanager.Settings) string {
+	if settings.Resolve == "" {
+		return correlation.ModeReply
+	}
+	return settings.Resolve
+}
+
+// dedupSettings возвращает настройки дедупликации экземпляра для формы
+func dedupSettings(instance *domain.IntegrationInstance) *dedup.Settings {
+	settings, err := dedup.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &dedup.Settings{}
+	}
+	return settings
+}
+
+// filterSettings возвращает правила фильтрации экземпляра для формы
+func filterSettings(instance *domain.IntegrationInstance) *filter.Settings {
+	settings, err := filter.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &filter.Settings{Default: filter.ActionSend}
+	}
+	return settings
+}
+
+// conditionRow - условие в виде строки формы: поле (путь, заголовок или метка) и значение
+type conditionRow struct {
+	Match string `json:"match"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// newConditionRow переводит условие в строку формы
+func newConditionRow(c filter.Condition) conditionRow {
+	row := conditionRow{Match: c.Match, Value: c.Pattern}
+	switch c.Match {
+	case filter.MatchJSONPath:
+		row.Field = c.Path
+	case filter.MatchHeader:
+		row.Field = c.Header
+	case filter.MatchLabel:
+		row.Field = c.Label
+	case filter.MatchLiquid:
+		row.Value = c.Expr
+	}
+	return row
+}
+
+// filterRulesData возвращает x-data Alpine с правилами в виде строк формы
+func filterRulesData(settings *filter.Settings) string {
+	type row struct {
+		conditionRow
+		Action string `json:"action"`
+	}
+
+	rows := make([]row, 0, len(settings.Rules))
+	for _, rule := range settings.Rules {
+		rows = append(rows, row{conditionRow: newConditionRow(rule.Condition), Action: rule.Action})
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"rules": rows})
+	return string(data)
+}
+
+// routingSettings возвращает маршруты экземпляра для формы
+func routingSettings(instance *domain.IntegrationInstance) *routing.Settings {
+	settings, err := routing.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &routing.Settings{}
+	}
+	return settings
+}
+
+// routesData возвращает x-data Alpine с маршрутами в виде строк формы
+func routesData(settings *routing.Settings) string {
+	type row struct {
+		conditionRow
+		Name     string `json:"name"`
+		ChatID   string `json:"chat_id"`
+		Login    string `json:"login"`
+		Template string `json:"template"`
+		Continue bool   `json:"continue"`
+	}
+
+	rows := make([]row, 0, len(settings.Routes))
+	for _, route := range settings.Routes {
+		rows = append(rows, row{
+			conditionRow: newConditionRow(route.Condition),
+			Name:         route.Name,
+			ChatID:       route.ChatID,
+			Login:        route.Login,
+			Template:     route.Template,
+			Continue:     route.Continue,
+		})
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"rules": rows})
+	return string(data)
+}
+
+// directSettings возвращает настройки личных сообщений экземпляра для формы
+func directSettings(instance *domain.IntegrationInstance) *recipients.Settings {
+	settings, err := recipients.FromCustomSettings(instance.CustomSettings)
+	if err != nil || settings == nil {
+		return &recipients.Settings{}
+	}
+	return settings
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -17,9 +18,20 @@ type Client struct {
 }
 
 type SendMessageRequest struct {
-	ChatID string `json:"chat_id,omitempty"`
-	Login  string `json:"login,omitempty"`
-	Text   string `json:"text"`
+	ChatID                string         `json:"chat_id,omitempty"`
+	Login                 string         `json:"login,omitempty"`
+	Text                  string         `json:"text"`
+	ReplyMessageID        int64          `json:"reply_message_id,omitempty"`
+	ThreadID              int64          `json:"thread_id,omitempty"`
+	DisableWebPagePreview bool           `json:"disable_web_page_preview,omitempty"`
+	InlineKeyboard        []InlineButton `json:"inline_keyboard,omitempty"`
+}
+
+// InlineButton - кнопка под сообщением: ссылка или callback с произвольными данными
+type InlineButton struct {
+	Text         string                 `json:"text"`
+	URL          string                 `json:"url,omitempty"`
+	CallbackData map[string]interface{} `json:"callback_data,omitempty"`
 }
 
 type SendMessageResponse struct {
@@ -27,6 +39,16 @@ type SendMessageResponse struct {
 	Ok        bool  `json:"ok"`
 }
 
+// SendResult - результат обращения к Bot API (заполняется и при ошибке, если ответ был получен)
+type SendResult struct {
+	StatusCode int
+	Body       string
+	MessageID  int64
+}
+
+// maxResponseBody - сколько байт ответа Bot API сохраняем для истории доставок
+const maxResponseBody = 64 * 1024
+
 func NewClient(token string) *Client {
 	return &Client{
 		token:   token,
@@ -37,58 +59,91 @@ func NewClient(token string) *Client {
 	}
 }
 
-func (c *Client) SendToChat(ctx context.Context, chatID, text string, keyboard interface{}) error {
-	req := SendMessageRequest{
-		ChatID: chatID,
-		Text:   text,
-	}
-
-	return c.sendMessage(ctx, "/messages/sendText/", req)
+func (c *Client) SendToChat(ctx context.Context, chatID, text string, keyboard []InlineButton) error {
+	_, err := c.SendText(ctx, SendMessageRequest{
+		ChatID:         chatID,
+		Text:           text,
+		InlineKeyboard: keyboard,
+	})
+	return err
 }
 
-func (c *Client) SendToLogin(ctx context.Context, login, text string, keyboard interface{}) error {
-	req := SendMessageRequest{
-		Login: login,
-		Text:  text,
-	}
+func (c *Client) SendToLogin(ctx context.Context, login, text string, keyboard []InlineButton) error {
+	_, err := c.SendText(ctx, SendMessageRequest{
+		Login:          login,
+		Text:           text,
+		InlineKeyboard: keyboard,
+	})
+	return err
+}
 
+// SendText отправляет текстовое сообщение и возвращает ответ Bot API
+func (c *Client) SendText(ctx context.Context, req SendMessageRequest) (*SendResult, error) {
 	return c.sendMessage(ctx, "/messages/sendText/", req)
 }
 
-func (c *Client) sendMessage(ctx context.Context, path string, req interface{}) error {
-	url := c.baseURL + path
-
+func (c *Client) sendMessage(ctx context.Context, path string, req interface{}) (*SendResult, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	return c.post(ctx, path, "application/json", bytes.NewBuffer(body))
+}
+
+// post выполняет запрос к Bot API и разбирает ответ
+func (c *Client) post(ctx context.Context, path, contentType string, body io.Reader) (*SendResult, error) {
+	url := c.baseURL + path
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Content-Type", contentType)
 	httpReq.Header.Set("Authorization", "OAuth "+c.token)
 
 	resp, err := c.http.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	result := &SendResult{
+		StatusCode: resp.StatusCode,
+		Body:       string(respBody),
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error: %s", resp.Status)
+		return result, fmt.Errorf("API error: %s", resp.Status)
 	}
 
-	var result SendMessageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	var decoded SendMessageResponse
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		return result, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if !result.Ok {
-		return fmt.Errorf("API returned not ok")
+	if !decoded.Ok {
+		return result, fmt.Errorf("API returned not ok")
 	}
 
-	return nil
+	result.MessageID = decoded.MessageID
+	return result, nil
+}
+
+// DeleteMessageRequest - запрос на удаление сообщения бота
+type DeleteMessageRequest struct {
+	ChatID    string `json:"chat_id,omitempty"`
+	Login     string `json:"login,omitempty"`
+	MessageID int64  `json:"message_id"`
+}
+
+// DeleteMessage удаляет ранее отправленное ботом сообщение
+func (c *Client) DeleteMessage(ctx context.Context, req DeleteMessageRequest) (*SendResult, error) {
+	return c.sendMessage(ctx, "/messages/delete/", req)
 }
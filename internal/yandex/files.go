@@ -0,0 +1,67 @@
+// Путь: internal/yandex/files.go
+package yandex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strconv"
+)
+
+// SendFileRequest - отправка файла или изображения (multipart/form-data)
+type SendFileRequest struct {
+	ChatID   string
+	Login    string
+	ThreadID int64
+	Filename string
+	Content  []byte
+}
+
+// SendFile отправляет файл как документ
+func (c *Client) SendFile(ctx context.Context, req SendFileRequest) (*SendResult, error) {
+	return c.sendMultipart(ctx, "/messages/sendFile/", "document", req)
+}
+
+// SendImage отправляет изображение
+func (c *Client) SendImage(ctx context.Context, req SendFileRequest) (*SendResult, error) {
+	return c.sendMultipart(ctx, "/messages/sendImage/", "image", req)
+}
+
+func (c *Client) sendMultipart(ctx context.Context, path, field string, req SendFileRequest) (*SendResult, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	fields := map[string]string{
+		"chat_id": req.ChatID,
+		"login":   req.Login,
+	}
+	if req.ThreadID != 0 {
+		fields["thread_id"] = strconv.FormatInt(req.ThreadID, 10)
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("failed to write field %s: %w", name, err)
+		}
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = field
+	}
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(req.Content); err != nil {
+		return nil, fmt.Errorf("failed to write file content: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish multipart body: %w", err)
+	}
+
+	return c.post(ctx, path, writer.FormDataContentType(), &body)
+}
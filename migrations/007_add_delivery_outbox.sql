@@ -0,0 +1,22 @@
+-- Очередь исходящих сообщений (outbox): сообщения переживают рестарт пода
+CREATE TABLE IF NOT EXISTS delivery_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id UUID NOT NULL REFERENCES integration_instances(id) ON DELETE CASCADE,
+    chat_id TEXT NOT NULL,
+    message TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 4,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Индексы для выборки воркерами
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON delivery_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_sending ON delivery_outbox(locked_until) WHERE status = 'sending';
+CREATE INDEX IF NOT EXISTS idx_outbox_instance_status ON delivery_outbox(instance_id, status);
+
+COMMENT ON TABLE delivery_outbox IS 'Исходящие сообщения, ожидающие отправки в Яндекс Мессенджер';
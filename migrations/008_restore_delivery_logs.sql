@@ -0,0 +1,22 @@
+-- История доставок по экземплярам: входящие вебхуки и попытки отправки
+CREATE TABLE IF NOT EXISTS delivery_logs (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id UUID NOT NULL REFERENCES integration_instances(id) ON DELETE CASCADE,
+    outbox_id BIGINT,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    request_headers JSONB,
+    request_payload TEXT,
+    rendered_text TEXT,
+    response_status INT,
+    response_body TEXT,
+    error TEXT,
+    duration_ms INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Индексы для постраничного просмотра и очистки по сроку хранения
+CREATE INDEX IF NOT EXISTS idx_delivery_logs_instance_created ON delivery_logs(instance_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_delivery_logs_created ON delivery_logs(created_at);
+
+COMMENT ON TABLE delivery_logs IS 'История входящих вебхуков и попыток отправки сообщений';
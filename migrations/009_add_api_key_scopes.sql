@@ -0,0 +1,5 @@
+-- Персональные API-ключи: видимый префикс для списка ключей и режим только для чтения
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS key_prefix TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS read_only BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
@@ -0,0 +1,2 @@
+-- Параметры сообщения из front matter шаблона: кнопки, ответ, тред, вложения
+ALTER TABLE delivery_outbox ADD COLUMN IF NOT EXISTS options JSONB;
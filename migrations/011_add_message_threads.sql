@@ -0,0 +1,18 @@
+-- Связь ключа корреляции (например, ключа задачи Jira) с отправленным сообщением
+CREATE TABLE IF NOT EXISTS message_threads (
+    instance_id UUID NOT NULL REFERENCES integration_instances(id) ON DELETE CASCADE,
+    correlation_key TEXT NOT NULL,
+    chat_id TEXT NOT NULL,
+    message_id BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (instance_id, correlation_key, chat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_threads_expires ON message_threads(expires_at);
+
+-- Ключ корреляции сообщения в очереди (пустой - без группировки)
+ALTER TABLE delivery_outbox ADD COLUMN IF NOT EXISTS correlation_key TEXT NOT NULL DEFAULT '';
+
+COMMENT ON TABLE message_threads IS 'Сообщения, к которым привязываются последующие события по тому же ключу';
@@ -0,0 +1,12 @@
+-- Последнее отправленное состояние группы или алерта Alertmanager.
+-- Повторное уведомление с тем же отпечатком (repeat_interval) не отправляется
+CREATE TABLE IF NOT EXISTS alert_states (
+    instance_id UUID NOT NULL REFERENCES integration_instances(id) ON DELETE CASCADE,
+    state_key TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (instance_id, state_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_states_expires ON alert_states(expires_at);
@@ -0,0 +1,10 @@
+-- Ключи идемпотентности принятых вебхуков. Повтор с тем же ключом до expires_at считается дублем
+CREATE TABLE IF NOT EXISTS webhook_dedup (
+    instance_id UUID NOT NULL REFERENCES integration_instances(id) ON DELETE CASCADE,
+    dedup_key TEXT NOT NULL,
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (instance_id, dedup_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dedup_expires ON webhook_dedup(expires_at);
@@ -0,0 +1,2 @@
+-- Счётчик отфильтрованных событий в списке интеграций
+CREATE INDEX IF NOT EXISTS idx_delivery_logs_dropped ON delivery_logs(instance_id) WHERE status = 'dropped';
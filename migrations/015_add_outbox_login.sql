@@ -0,0 +1,2 @@
+-- Личные сообщения: получатель задаётся логином Яндекс 360 вместо ID чата
+ALTER TABLE delivery_outbox ADD COLUMN IF NOT EXISTS login TEXT NOT NULL DEFAULT '';
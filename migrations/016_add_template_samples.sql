@@ -0,0 +1,18 @@
+-- Именованные примеры входящих данных шаблонов: предпросмотр, тесты шаблонов и документация.
+-- Тело хранится как last_webhook_body: не-JSON - JSON-строкой, заголовки - как last_webhook_headers
+CREATE TABLE IF NOT EXISTS template_samples (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    headers JSONB,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (template_id, name)
+);
+
+-- Единственный пример из templates.sample_payload становится примером default
+INSERT INTO template_samples (template_id, name, payload)
+SELECT id, 'default', sample_payload FROM templates WHERE sample_payload IS NOT NULL
+ON CONFLICT (template_id, name) DO NOTHING;
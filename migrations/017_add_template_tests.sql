@@ -0,0 +1,2 @@
+-- Тесты шаблона: входные данные и ожидаемый текст сообщения (точное совпадение, подстрока или регулярное выражение)
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS tests JSONB NOT NULL DEFAULT '[]'::jsonb;
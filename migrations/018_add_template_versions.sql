@@ -0,0 +1,26 @@
+-- Неизменяемая история версий шаблонов: каждое сохранение добавляет строку, откат создаёт новую версию
+CREATE TABLE IF NOT EXISTS template_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL DEFAULT '',
+    template_text TEXT NOT NULL,
+    tests JSONB NOT NULL DEFAULT '[]'::jsonb,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (template_id, version)
+);
+
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- Закреплённая версия шаблона экземпляра (NULL - последняя)
+ALTER TABLE integration_instances ADD COLUMN IF NOT EXISTS template_version INT;
+
+-- Текущее состояние существующих шаблонов становится версией 1
+INSERT INTO template_versions (template_id, version, name, description, icon, template_text, tests, author_id, note, created_at)
+SELECT id, version, name, COALESCE(description, ''), COALESCE(icon, ''), template_text, tests, created_by, '', updated_at
+FROM templates
+ON CONFLICT (template_id, version) DO NOTHING;
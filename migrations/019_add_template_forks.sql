@@ -0,0 +1,6 @@
+-- Личная копия общего шаблона, созданная при правке шаблона из интеграции:
+-- исходный шаблон и его версия на момент копирования (для переноса обновлений исходного шаблона)
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS forked_from UUID REFERENCES templates(id) ON DELETE SET NULL;
+ALTER TABLE templates ADD COLUMN IF NOT EXISTS forked_from_version INT;
+
+CREATE INDEX IF NOT EXISTS idx_templates_forked_from ON templates(forked_from);
@@ -0,0 +1,9 @@
+-- Шаблоны встроенного каталога, установленные в базу: ключ каталога, ревизия и версия шаблона, записанная каталогом.
+-- Удалённый администратором шаблон остаётся строкой с template_id = NULL и заново не устанавливается
+CREATE TABLE IF NOT EXISTS catalog_templates (
+    key VARCHAR(100) PRIMARY KEY,
+    template_id UUID REFERENCES templates(id) ON DELETE SET NULL,
+    revision INT NOT NULL,
+    template_version INT NOT NULL,
+    synced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Части сообщения (текст, изображение, файл), уже отправленные в мессенджер.
+-- Повторная попытка отправляет только оставшиеся части, поэтому текст не дублируется.
+ALTER TABLE delivery_outbox ADD COLUMN IF NOT EXISTS progress JSONB;
@@ -0,0 +1,9 @@
+-- Тело не в формате JSON хранилось JSON-строкой, и его нельзя было отличить от тела-JSON-строки.
+-- Теперь такое тело хранится в обёртке с явной кодировкой: {"encoding": "text", "body": "..."}.
+UPDATE integration_instances
+SET last_webhook_body = jsonb_build_object('encoding', 'text', 'body', last_webhook_body #>> '{}')
+WHERE jsonb_typeof(last_webhook_body) = 'string';
+
+UPDATE template_samples
+SET payload = jsonb_build_object('encoding', 'text', 'body', payload #>> '{}')
+WHERE jsonb_typeof(payload) = 'string';
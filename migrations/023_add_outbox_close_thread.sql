@@ -0,0 +1,3 @@
+-- Последнее сообщение по ключу корреляции (решённая группа Alertmanager): после отправки связь ключа
+-- с сообщением удаляется, и новое срабатывание начинает новое сообщение вместо ответа на старое.
+ALTER TABLE delivery_outbox ADD COLUMN IF NOT EXISTS close_thread BOOLEAN NOT NULL DEFAULT false;